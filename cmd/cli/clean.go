@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/search"
+)
+
+var cleanOlderThan time.Duration
+
+// newCleanCmd creates the ops clean subcommand for partial copies
+func newCleanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean <target directories...>",
+		Short: "Remove partial files left by interrupted copies",
+		Long: `Remove the partial files that interrupted copies of large files keep in
+their target directory so a later copy can continue, and the *.tmp files of
+copies that crashed before their first checkpoint. Only partial files whose
+checkpoint, or for files without one the file itself, was not updated within
+--older-than are removed, so copies that are still running are left alone.`,
+		Example: `  koe ops clean /mnt/backup
+  koe ops clean --older-than 0 /mnt/backup`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			failed := false
+			for _, dir := range args {
+				removed, err := search.CleanPartialCopies(dir, cleanOlderThan)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					failed = true
+				}
+				fmt.Printf("Removed %d partial copies from %s\n", removed, dir)
+			}
+			if failed {
+				os.Exit(exitError)
+			}
+		},
+	}
+	cmd.Flags().DurationVar(&cleanOlderThan, "older-than", 24*time.Hour, "Only remove partial copies untouched for this long")
+	return cmd
+}
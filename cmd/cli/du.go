@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+var (
+	duDepth int
+	duTop   int
+)
+
+// jsonDirUsage is the JSON representation of a disk usage tree node
+type jsonDirUsage struct {
+	Path       string          `json:"path"`
+	Size       int64           `json:"size"`
+	Files      int             `json:"files"`
+	Extensions map[string]int  `json:"extensions"`
+	Children   []*jsonDirUsage `json:"children,omitempty"`
+}
+
+// newDuCmd creates the du subcommand
+func newDuCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "du [directories...]",
+		Short: "Show which directories use the most space",
+		Example: `  koe du /data
+  koe du --depth 3 --top 5 -e log /var/log`,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, format, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			opts.StopChan = newStopChan("Disk usage scan interrupted by user")
+			opts.Progress = search.NewSearchProgress()
+			trees := search.DiskUsage(opts)
+
+			for _, tree := range trees {
+				if format == config.OutputJSON {
+					json.NewEncoder(os.Stdout).Encode(toJSONDirUsage(tree, duDepth, duTop))
+					continue
+				}
+				printDirUsage(os.Stdout, tree, "", "", duDepth, duTop)
+			}
+
+			if errs := opts.Progress.Snapshot().Errors; errs > 0 {
+				fmt.Fprintf(os.Stderr, "\n%d paths could not be read\n", errs)
+				os.Exit(exitError)
+			}
+		},
+	}
+	addSearchFlags(cmd)
+	cmd.Flags().IntVar(&duDepth, "depth", 2, "Number of directory levels to show below each root (0 for all)")
+	cmd.Flags().IntVar(&duTop, "top", 10, "Number of largest subdirectories to show per directory (0 for all)")
+	return cmd
+}
+
+// toJSONDirUsage converts a tree limited to depth levels and top children
+func toJSONDirUsage(d *search.DirUsage, depth, top int) *jsonDirUsage {
+	out := &jsonDirUsage{
+		Path:       d.Path,
+		Size:       d.TotalSize,
+		Files:      d.FileCount,
+		Extensions: d.CommonExts,
+	}
+	if depth == 1 {
+		return out
+	}
+	for i, child := range d.Children {
+		if top > 0 && i == top {
+			break
+		}
+		out.Children = append(out.Children, toJSONDirUsage(child, depth-1, top))
+	}
+	return out
+}
+
+// printDirUsage writes a tree node and its largest children as text
+func printDirUsage(w io.Writer, d *search.DirUsage, prefix, branch string, depth, top int) {
+	name := d.Path
+	if branch != "" {
+		name = filepath.Base(d.Path)
+	}
+
+	exts := make([]string, 0, 3)
+	for _, e := range d.TopExts(3) {
+		ext := e.Ext
+		if ext == "" {
+			ext = "(none)"
+		}
+		exts = append(exts, fmt.Sprintf("%s %d", ext, e.Count))
+	}
+
+	fmt.Fprintf(w, "%10s %8d files  %s%s%s  [%s]\n",
+		formatSize(d.TotalSize), d.FileCount, prefix, branch, name, strings.Join(exts, ", "))
+
+	if depth == 1 {
+		return
+	}
+
+	children := d.Children
+	hidden := 0
+	if top > 0 && len(children) > top {
+		hidden = len(children) - top
+		children = children[:top]
+	}
+
+	// Children are indented under the branch of their parent
+	childPrefix := prefix
+	switch branch {
+	case "├── ":
+		childPrefix += "│   "
+	case "└── ":
+		childPrefix += "    "
+	}
+
+	for i, child := range children {
+		childBranch := "├── "
+		if i == len(children)-1 && hidden == 0 {
+			childBranch = "└── "
+		}
+		printDirUsage(w, child, childPrefix, childBranch, depth-1, top)
+	}
+	if hidden > 0 {
+		fmt.Fprintf(w, "%10s %8s        %s└── ... %d more\n", "", "", childPrefix, hidden)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// jsonDuplicateGroup is the JSON representation of a duplicate group
+type jsonDuplicateGroup struct {
+	Size   int64    `json:"size"`
+	Hash   string   `json:"hash"`
+	Wasted int64    `json:"wasted"`
+	Files  []string `json:"files"`
+}
+
+// newDupesCmd creates the dupes subcommand
+func newDupesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dupes [directories...]",
+		Short: "Find files with identical content",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, format, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			opts.StopChan = newStopChan("Duplicate search interrupted by user")
+			groups := search.FindDuplicates(opts)
+
+			var wasted int64
+			encoder := json.NewEncoder(os.Stdout)
+			for _, group := range groups {
+				wasted += group.Wasted()
+
+				if format == config.OutputJSON {
+					files := make([]string, len(group.Files))
+					for i, f := range group.Files {
+						files[i] = f.Path
+					}
+					encoder.Encode(jsonDuplicateGroup{
+						Size:   group.Size,
+						Hash:   fmt.Sprintf("%016x", group.Hash),
+						Wasted: group.Wasted(),
+						Files:  files,
+					})
+					continue
+				}
+
+				fmt.Printf("\n%d files, %s each (%s wasted):\n", len(group.Files), formatSize(group.Size), formatSize(group.Wasted()))
+				for _, f := range group.Files {
+					fmt.Printf("  %s\n", f.Path)
+				}
+			}
+
+			if format == config.OutputText {
+				fmt.Printf("\n%d duplicate groups, %s reclaimable\n", len(groups), formatSize(wasted))
+			}
+		},
+	}
+	addSearchFlags(cmd)
+	return cmd
+}
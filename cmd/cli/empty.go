@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+var (
+	emptyType   string
+	emptyDelete bool
+)
+
+// newEmptyCmd creates the empty subcommand
+func newEmptyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "empty [directories...]",
+		Short: "Find zero-byte files and empty directory trees",
+		Long: `Find zero-byte files and directories that contain no files, only other
+empty directories. The search roots themselves are never reported.
+
+With --delete, empty files are removed first and empty directories are then
+removed bottom-up. Directories that gained files in the meantime are kept.`,
+		Example: `  koe empty /srv/build
+  koe empty --type dirs --delete /srv/build`,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, format, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			wantFiles, wantDirs := true, true
+			switch emptyType {
+			case "files":
+				wantDirs = false
+			case "dirs":
+				wantFiles = false
+			case "all":
+			default:
+				fmt.Printf("unknown type: %s (use files, dirs or all)\n", emptyType)
+				os.Exit(exitError)
+			}
+
+			stopChan := newStopChan("Search interrupted by user")
+			opts.StopChan = stopChan
+			opts.EmptyFiles = true
+			opts.EmptyDirs = wantDirs
+
+			files := make([]string, 0)
+			dirs := make([]string, 0)
+			for result := range search.Search(opts) {
+				if result.Error != nil {
+					continue
+				}
+				isDir := result.Mode.IsDir()
+				if (isDir && !wantDirs) || (!isDir && !wantFiles) {
+					continue
+				}
+
+				printResult(os.Stdout, result, format)
+				if isDir {
+					dirs = append(dirs, result.Path)
+				} else {
+					files = append(files, result.Path)
+				}
+			}
+
+			select {
+			case <-stopChan:
+				os.Exit(exitError)
+			default:
+			}
+
+			// Keep stdout machine-readable in JSON mode
+			summary := os.Stdout
+			if format == config.OutputJSON {
+				summary = os.Stderr
+			}
+			fmt.Fprintf(summary, "\nEmpty files: %d, empty directories: %d\n", len(files), len(dirs))
+
+			if !emptyDelete {
+				if len(files)+len(dirs) == 0 {
+					os.Exit(exitNotFound)
+				}
+				return
+			}
+
+			failed := 0
+			removedFiles := 0
+			deleteOp := search.FileOperationOptions{Operation: search.DeleteFiles}
+			for _, path := range files {
+				// Skip files written to since they were found
+				if info, err := os.Lstat(path); err != nil || info.Size() != 0 {
+					continue
+				}
+				if err := search.HandleFileOperation(path, deleteOp); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to delete %s: %v\n", path, err)
+					failed++
+					continue
+				}
+				removedFiles++
+			}
+
+			removedDirs, errs := search.RemoveEmptyDirs(dirs)
+			for _, err := range errs {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			failed += len(errs)
+
+			fmt.Fprintf(summary, "Removed %d files and %d directories, %d failed\n", removedFiles, removedDirs, failed)
+			if failed > 0 {
+				os.Exit(exitError)
+			}
+		},
+	}
+
+	addSearchFlags(cmd)
+	addResultFlags(cmd)
+	cmd.Flags().StringVar(&emptyType, "type", "all", "What to find: files, dirs or all")
+	cmd.Flags().BoolVar(&emptyDelete, "delete", false, "Remove what was found (directories bottom-up)")
+	return cmd
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+var indexPath string
+
+// defaultIndexPath returns the index location used when --index is not given
+func defaultIndexPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %v", err)
+	}
+	return filepath.Join(dir, "koe", "index.gob"), nil
+}
+
+// resolveIndexPath returns --index or the default location
+func resolveIndexPath() (string, error) {
+	if indexPath != "" {
+		return indexPath, nil
+	}
+	return defaultIndexPath()
+}
+
+// newIndexCmd creates the index subcommand with build, query and stats
+func newIndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Build and query a persistent file index",
+	}
+	cmd.PersistentFlags().StringVar(&indexPath, "index", "", "Path to the index file (default: user cache dir/koe/index.gob)")
+
+	build := &cobra.Command{
+		Use:   "build [directories...]",
+		Short: "Walk directories and save every matching file to the index",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, _, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			path, err := resolveIndexPath()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			opts.StopChan = newStopChan("Index build interrupted by user")
+			start := time.Now()
+			index := search.BuildIndex(opts)
+
+			if err := search.SaveIndex(index, path); err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+			fmt.Printf("Indexed %d files in %v -> %s\n", len(index.Files), time.Since(start).Round(time.Millisecond), path)
+		},
+	}
+	addSearchFlags(build)
+
+	query := &cobra.Command{
+		Use:   "query [directories...]",
+		Short: "Search the index instead of the file system",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, format, err := buildSearchOptions(cmd, args)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			index, err := loadIndex()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			results := index.Query(opts)
+			for _, result := range results {
+				printResult(os.Stdout, result, format)
+			}
+			if format == config.OutputText {
+				fmt.Printf("\nTotal files found: %d\n", len(results))
+			}
+			if len(results) == 0 {
+				os.Exit(exitNotFound)
+			}
+		},
+	}
+	addSearchFlags(query)
+	addResultFlags(query)
+
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "Show index statistics",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			_, format, err := buildSearchOptions(cmd, args)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			index, err := loadIndex()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			s := index.Stats()
+			if format == config.OutputJSON {
+				json.NewEncoder(os.Stdout).Encode(s)
+				return
+			}
+
+			fmt.Printf("Files:       %d\n", s.Files)
+			fmt.Printf("Directories: %d\n", s.Dirs)
+			fmt.Printf("Total size:  %s\n", formatSize(s.TotalSize))
+			fmt.Printf("Last build:  %s\n", s.LastBuild.Format(time.RFC3339))
+			fmt.Println("Top extensions:")
+			for i, ext := range s.TopExts {
+				if i == 10 {
+					break
+				}
+				name := ext.Ext
+				if name == "" {
+					name = "(none)"
+				}
+				fmt.Printf("  %-10s %d\n", name, ext.Count)
+			}
+		},
+	}
+
+	cmd.AddCommand(build, query, stats)
+	return cmd
+}
+
+// loadIndex loads the index from --index or the default location
+func loadIndex() (*search.FileIndex, error) {
+	path, err := resolveIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	index, err := search.LoadIndex(path)
+	if err != nil {
+		return nil, fmt.Errorf("%v (run \"koe index build\" first)", err)
+	}
+	return index, nil
+}
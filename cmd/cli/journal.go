@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// openJournal prepares a journal for one ops run in the default directory
+func openJournal() (*search.Journal, error) {
+	dir, err := search.DefaultJournalDir()
+	if err != nil {
+		return nil, err
+	}
+	return search.NewJournal(dir)
+}
+
+// newJournalCmd creates the ops journal subcommand
+func newJournalCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "journal [journal-id]",
+		Short: "List recorded operations, or show the entries of one journal",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := search.DefaultJournalDir()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			if len(args) == 1 {
+				entries, err := search.ReadJournal(dir, args[0])
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(exitError)
+				}
+				enc := json.NewEncoder(os.Stdout)
+				for _, e := range entries {
+					if outputFormat == config.OutputJSON {
+						enc.Encode(e)
+						continue
+					}
+					target := ""
+					if e.Target != "" {
+						target = " -> " + e.Target
+					}
+					fmt.Printf("%s  %-7s %10s  %s%s\n", e.Time.Format("2006-01-02 15:04:05"), e.Operation, formatSize(e.Size), e.Source, target)
+				}
+				return
+			}
+
+			journals, err := search.ListJournals(dir)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+			for _, j := range journals {
+				state := ""
+				if j.Undone {
+					state = " (undone)"
+				} else if j.UndoneFiles > 0 {
+					state = fmt.Sprintf(" (%d undone)", j.UndoneFiles)
+				}
+				fmt.Printf("%s  %-7s %6d files %10s%s\n", j.ID, j.Operation, j.Files, formatSize(j.Bytes), state)
+			}
+		},
+	}
+}
+
+// newUndoCmd creates the ops undo subcommand
+func newUndoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo <journal-id>",
+		Short: "Reverse the moves, copies and trashing recorded in a journal",
+		Long: `Reverse a recorded operation: moved files are moved back, trashed files are
+restored and copies are removed. Files that changed since the operation are
+left alone. Permanent deletions cannot be undone, and neither can copies made
+with --conflict overwrite, since the file they replaced is gone.
+Running undo again retries the files that could not be undone.`,
+		Example: `  koe ops journal
+  koe ops undo 20261018-142501-a1b2c3`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := search.DefaultJournalDir()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			undone, errs := search.UndoJournal(dir, args[0])
+			for _, err := range errs {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			fmt.Printf("Undid %d operations, %d failed\n", undone, len(errs))
+			if len(errs) > 0 {
+				os.Exit(exitError)
+			}
+		},
+	}
+}
@@ -4,27 +4,50 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
-	"context"
-	"os/signal"
 	"syscall"
 
 	"github.com/spf13/cobra"
-	"github.com/schollz/progressbar/v3"
-	
+
+	"filesearch/internal/config"
 	"filesearch/internal/search"
 )
 
 var (
-	patterns        []string
-	extensions      []string
-	ignoreCase      bool
-	workers         int
-	bufferSize      int
-	showSize        bool
-	openInExplorer  bool
-	showVersion     bool
+	patterns       []string
+	extensions     []string
+	ignoreCase     bool
+	workers        int
+	bufferSize     int
+	showSize       bool
+	openInExplorer bool
+	showVersion    bool
+	excludeDirs    []string
+	minSize        string
+	maxSize        string
+	minAge         string
+	maxAge         string
+	outputFormat   string
+	configPath     string
+	profileName    string
+	listErrors     bool
+	showSummary    bool
+	owner          string
+	group          string
+	perm           string
+	archives       bool
+	newerRefs      = make(map[string]*string)
+	dateBounds     = make(map[string]*string)
+)
+
+// Exit codes follow grep: 0 when something was found, 1 when nothing
+// matched and 2 when an error occurred
+const (
+	exitFound    = 0
+	exitNotFound = 1
+	exitError    = 2
 )
 
 // formatSize formats file size in human-readable form
@@ -41,11 +64,219 @@ func formatSize(size int64) string {
 	}
 }
 
+// loadConfig reads the config file from --config or the default location
+func loadConfig() (*config.Config, error) {
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return config.Load(path)
+}
+
+// buildSearchOptions layers config defaults, the selected profile and
+// explicitly set flags (in that order) into search options
+func buildSearchOptions(cmd *cobra.Command, args []string) (search.SearchOptions, string, error) {
+	opts := search.SearchOptions{
+		MaxWorkers: workers,
+		IgnoreCase: ignoreCase,
+		BufferSize: bufferSize,
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return opts, "", err
+	}
+
+	profile, err := cfg.Profile(profileName)
+	if err != nil {
+		return opts, "", err
+	}
+	if err := profile.Apply(&opts); err != nil {
+		return opts, "", fmt.Errorf("profile %q: %v", profileName, err)
+	}
+
+	format := config.OutputText
+	if profile.Output != "" {
+		format = profile.Output
+	}
+
+	// Explicit flags override profile values
+	flags := cmd.Flags()
+	if len(args) > 0 {
+		opts.RootDirs = args
+	}
+	if flags.Changed("pattern") {
+		opts.Patterns = patterns
+	}
+	if flags.Changed("ext") {
+		opts.Extensions = extensions
+	}
+	if flags.Changed("ignore-case") {
+		opts.IgnoreCase = ignoreCase
+	}
+	if flags.Changed("workers") {
+		opts.MaxWorkers = workers
+	}
+	if flags.Changed("archives") {
+		opts.SearchArchives = archives
+	}
+	if flags.Changed("exclude") {
+		opts.ExcludeDirs = excludeDirs
+	}
+	if flags.Changed("output") {
+		format = outputFormat
+	}
+
+	overrides := config.Profile{
+		MinSize: minSize,
+		MaxSize: maxSize,
+		MinAge:  minAge,
+		MaxAge:  maxAge,
+		Owner:   owner,
+		Group:   group,
+		Perm:    perm,
+	}
+	if err := overrides.Apply(&opts); err != nil {
+		return opts, "", err
+	}
+
+	if opts.TimeFilters, err = timeFilters(); err != nil {
+		return opts, "", err
+	}
+
+	if format != config.OutputText && format != config.OutputJSON {
+		return opts, "", fmt.Errorf("unknown output format: %s", format)
+	}
+	if opts.MaxWorkers <= 0 {
+		opts.MaxWorkers = runtime.NumCPU()
+	}
+
+	return opts, format, nil
+}
+
+// dateFlags maps the date flags to the timestamp and bound they set
+var dateFlags = []struct {
+	name   string
+	field  search.TimeField
+	before bool
+	usage  string
+}{
+	{"after", search.ModifiedTime, false, "Only files modified after this date (e.g. 2026-09-01, \"2026-09-01 14:30\")"},
+	{"before", search.ModifiedTime, true, "Only files modified before this date"},
+	{"changed-after", search.ChangedTime, false, "Only files whose status changed (ctime) after this date"},
+	{"changed-before", search.ChangedTime, true, "Only files whose status changed (ctime) before this date"},
+	{"accessed-after", search.AccessedTime, false, "Only files accessed after this date"},
+	{"accessed-before", search.AccessedTime, true, "Only files accessed before this date"},
+}
+
+// newerFlags maps the find-style reference file flags to the timestamp they
+// compare with the modification time of the reference file
+var newerFlags = []struct {
+	name  string
+	field search.TimeField
+	usage string
+}{
+	{"newer", search.ModifiedTime, "Only files modified after the reference file was"},
+	{"cnewer", search.ChangedTime, "Only files whose status changed (ctime) after the reference file was modified"},
+	{"anewer", search.AccessedTime, "Only files accessed after the reference file was modified"},
+}
+
+// timeFilters builds absolute date filters from the reference file and date flags
+func timeFilters() ([]search.TimeFilter, error) {
+	filters := make([]search.TimeFilter, 0)
+
+	for _, flag := range newerFlags {
+		ref := *newerRefs[flag.name]
+		if ref == "" {
+			continue
+		}
+		t, err := search.FileTime(ref, search.ModifiedTime)
+		if err != nil {
+			return nil, fmt.Errorf("reference file for --%s: %v", flag.name, err)
+		}
+		filters = append(filters, search.TimeFilter{Field: flag.field, After: t})
+	}
+
+	for _, flag := range dateFlags {
+		value := *dateBounds[flag.name]
+		if value == "" {
+			continue
+		}
+		t, err := config.ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s %q: %v", flag.name, value, err)
+		}
+		filter := search.TimeFilter{Field: flag.field}
+		if flag.before {
+			filter.Before = t
+		} else {
+			filter.After = t
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// requireRoots fails when no directories were given on the command line or in the profile
+func requireRoots(opts search.SearchOptions) error {
+	if len(opts.RootDirs) == 0 {
+		return fmt.Errorf("no directories to search: pass them as arguments or set roots in the profile")
+	}
+	return nil
+}
+
+// newStopChan returns a channel that is closed on Ctrl+C or SIGTERM
+func newStopChan(message string) chan struct{} {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	stopChan := make(chan struct{})
+
+	go func() {
+		<-sigChan
+		fmt.Fprintf(os.Stderr, "\n%s\n", message)
+		close(stopChan)
+	}()
+
+	return stopChan
+}
+
+// addSearchFlags registers the flags that select which files are found
+func addSearchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&patterns, "pattern", "p", []string{}, "Search patterns (can be specified multiple times)")
+	cmd.Flags().StringSliceVarP(&extensions, "ext", "e", []string{}, "File extensions without dot (can be specified multiple times)")
+	cmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Ignore case")
+	cmd.Flags().BoolVar(&archives, "archives", false, "Also match entries inside zip, jar, tar, tar.gz and tar.zst files")
+	cmd.Flags().StringSliceVarP(&excludeDirs, "exclude", "x", []string{}, "Directories to exclude, replacing the profile's exclude_dirs (can be specified multiple times; --exclude= clears them)")
+	cmd.Flags().StringVar(&minSize, "min-size", "", "Minimum file size (e.g. 1KB, 1.5MB)")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Maximum file size (e.g. 1KB, 1.5MB)")
+	cmd.Flags().StringVar(&minAge, "min-age", "", "Minimum file age (e.g. 1h, 2d, 1w)")
+	cmd.Flags().StringVar(&maxAge, "max-age", "", "Maximum file age (e.g. 1h, 2d, 1w)")
+	for _, flag := range newerFlags {
+		if newerRefs[flag.name] == nil {
+			newerRefs[flag.name] = new(string)
+		}
+		cmd.Flags().StringVar(newerRefs[flag.name], flag.name, "", flag.usage)
+	}
+	for _, flag := range dateFlags {
+		if dateBounds[flag.name] == nil {
+			dateBounds[flag.name] = new(string)
+		}
+		cmd.Flags().StringVar(dateBounds[flag.name], flag.name, "", flag.usage)
+	}
+	cmd.Flags().StringVar(&owner, "owner", "", "Only files owned by this user name or uid")
+	cmd.Flags().StringVar(&group, "group", "", "Only files owned by this group name or gid")
+	cmd.Flags().StringVar(&perm, "perm", "", "Mode filter: 644 for exact mode, -0002 for all bits set (e.g. world-writable)")
+}
+
 // openFileLocation opens file location in explorer
 func openFileLocation(path string) error {
 	path = filepath.Clean(path)
 	var cmd *exec.Cmd
-	
+
 	switch runtime.GOOS {
 	case "windows":
 		cmdPath := os.Getenv("COMSPEC")
@@ -58,19 +289,51 @@ func openFileLocation(path string) error {
 	default: // Linux and other Unix-like systems
 		cmd = exec.Command("xdg-open", filepath.Dir(path))
 	}
-	
+
 	return cmd.Run()
 }
 
+// rootArgs rejects a first argument that is neither an existing path nor a
+// subcommand, so that a mistyped subcommand is not searched as a directory
+func rootArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(args[0]); err == nil {
+		return nil
+	}
+
+	msg := fmt.Sprintf("unknown command or directory %q for %q", args[0], cmd.CommandPath())
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2
+	}
+	if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+		msg += "\n\nDid you mean this?\n"
+		for _, s := range suggestions {
+			msg += fmt.Sprintf("\t%v\n", s)
+		}
+	}
+	return fmt.Errorf("%s", msg)
+}
+
 func main() {
 	var rootCmd = &cobra.Command{
-		Use:   "filesearch [directories...]",
+		Use:   "koe [directories...]",
 		Short: "Fast file search utility",
 		Long: `A high-performance file search utility with advanced features.
 Supports multiple patterns and extensions for searching.
-Example: filesearch -p "*.txt" -p "*.doc" -e txt -e doc -i /home /usr`,
+Example: koe search -p "*.txt" -p "*.doc" -e txt -e doc -i /home /usr
+
+Running koe without a subcommand is the same as "koe search", as long as
+the first argument is an existing directory.
+
+Defaults and named profiles are read from $XDG_CONFIG_HOME/koe/config.toml.
+Example: koe search --profile logs
+
+Exit status is 0 if any file was found, 1 if nothing matched and 2 if an
+error occurred (for example, a directory could not be read).`,
 		Version: search.Version,
-		Args:  cobra.MinimumNArgs(1),
+		Args:    rootArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			if showVersion {
 				fmt.Printf("Koe no Search v%s\n", search.Version)
@@ -78,94 +341,35 @@ Example: filesearch -p "*.txt" -p "*.doc" -e txt -e doc -i /home /usr`,
 				fmt.Printf("Git Commit: %s\n", search.GitCommit)
 				return
 			}
-
-			if workers <= 0 {
-				workers = runtime.NumCPU()
-			}
-
-			// Create context with cancellation
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-
-			// Handle Ctrl+C
-			sigChan := make(chan os.Signal, 1)
-			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-			stopChan := make(chan struct{})
-
-			go func() {
-				select {
-				case <-sigChan:
-					fmt.Println("\nSearch interrupted by user")
-					close(stopChan)
-					cancel()
-				case <-ctx.Done():
-					return
-				}
-			}()
-
-			opts := search.SearchOptions{
-				RootDirs:   args,
-				Patterns:   patterns,
-				Extensions: extensions,
-				MaxWorkers: workers,
-				IgnoreCase: ignoreCase,
-				BufferSize: bufferSize,
-				StopChan:   stopChan,
-			}
-
-			results := search.Search(opts)
-			
-			bar := progressbar.Default(-1, "Searching")
-			
-			count := 0
-			foundFiles := make([]string, 0)
-			
-			// Process search results
-			for result := range results {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					bar.Add(1)
-					if result.Error != nil {
-						fmt.Printf("\nError processing %s: %v\n", result.Path, result.Error)
-						continue
-					}
-					
-					sizeStr := ""
-					if showSize {
-						sizeStr = fmt.Sprintf(" (%s)", formatSize(result.Size))
-					}
-					
-					fmt.Printf("\nFound: %s%s\n", result.Path, sizeStr)
-					foundFiles = append(foundFiles, result.Path)
-					count++
-				}
-			}
-			
-			fmt.Printf("\nTotal files found: %d\n", count)
-			
-			// If only one file found and open in explorer option is enabled
-			if openInExplorer && len(foundFiles) > 0 {
-				fmt.Println("Opening file location...")
-				if err := openFileLocation(foundFiles[0]); err != nil {
-					fmt.Printf("Error opening file location: %v\n", err)
-				}
-			}
+			runSearch(cmd, args)
 		},
 	}
 
-	rootCmd.Flags().StringSliceVarP(&patterns, "pattern", "p", []string{}, "Search patterns (can be specified multiple times)")
-	rootCmd.Flags().StringSliceVarP(&extensions, "ext", "e", []string{}, "File extensions without dot (can be specified multiple times)")
-	rootCmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "Ignore case")
-	rootCmd.Flags().IntVarP(&workers, "workers", "w", 0, "Number of worker threads (default: number of CPU cores)")
-	rootCmd.Flags().IntVarP(&bufferSize, "buffer", "b", 1000, "Size of the internal buffers")
-	rootCmd.Flags().BoolVarP(&showSize, "size", "s", true, "Show file sizes")
-	rootCmd.Flags().BoolVarP(&openInExplorer, "open", "o", false, "Open file location in explorer (when single file found)")
+	rootCmd.PersistentFlags().IntVarP(&workers, "workers", "w", 0, "Number of worker threads (default: number of CPU cores)")
+	rootCmd.PersistentFlags().IntVarP(&bufferSize, "buffer", "b", 1000, "Size of the internal buffers")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", config.OutputText, "Output format: text or json")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to config file (default: $XDG_CONFIG_HOME/koe/config.toml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Named profile from the config file")
+
+	// The root command keeps the pre-subcommand invocation working
+	addSearchFlags(rootCmd)
+	addResultFlags(rootCmd)
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Show version information")
 
+	rootCmd.AddCommand(
+		newSearchCmd(),
+		newIndexCmd(),
+		newWatchCmd(),
+		newDupesCmd(),
+		newDuCmd(),
+		newEmptyCmd(),
+		newOpsCmd(),
+		newServeCmd(),
+		newTuiCmd(),
+	)
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+var (
+	targetDir         string
+	conflictPolicy    string
+	preserveStructure bool
+	flattenPaths      bool
+	preserveAttrs     []string
+	verifyCopies      bool
+	dryRun            bool
+	maxRate           string
+	maxFiles          int
+
+	archiveFormat    string
+	archiveName      string
+	archiveLevel     int
+	archiveManifest  bool
+	archiveSplitSize string
+)
+
+// parseConflictPolicy converts a --conflict value to a policy
+func parseConflictPolicy(s string) (search.ConflictResolutionPolicy, error) {
+	switch s {
+	case "skip":
+		return search.Skip, nil
+	case "overwrite":
+		return search.Overwrite, nil
+	case "rename":
+		return search.Rename, nil
+	default:
+		return search.Skip, fmt.Errorf("unknown conflict policy: %s (use skip, overwrite or rename)", s)
+	}
+}
+
+// parseAttributeFlags converts --preserve values to attribute flags
+func parseAttributeFlags(names []string) (search.AttributeFlags, error) {
+	var flags search.AttributeFlags
+	for _, name := range names {
+		switch name {
+		case "times":
+			flags |= search.PreserveTimes
+		case "mode":
+			flags |= search.PreserveMode
+		case "owner":
+			flags |= search.PreserveOwner
+		case "xattrs":
+			flags |= search.PreserveXattrs
+		case "all":
+			flags |= search.PreserveAll
+		default:
+			return 0, fmt.Errorf("unknown attribute: %s (use times, mode, owner, xattrs or all)", name)
+		}
+	}
+	return flags, nil
+}
+
+// parseArchiveFormat converts a --format value to an archive format
+func parseArchiveFormat(s string) (search.ArchiveFormat, error) {
+	switch s {
+	case "zip":
+		return search.ZipFormat, nil
+	case "tar.gz", "tgz":
+		return search.TarGzFormat, nil
+	default:
+		return search.ZipFormat, fmt.Errorf("unknown archive format: %s (use zip or tar.gz)", s)
+	}
+}
+
+// archiveOptions fills in the archive settings of fileOp from the flags
+func archiveOptions(fileOp *search.FileOperationOptions) error {
+	var err error
+	if fileOp.ArchiveFormat, err = parseArchiveFormat(archiveFormat); err != nil {
+		return err
+	}
+	if archiveSplitSize != "" {
+		if fileOp.SplitSize, err = config.ParseSize(archiveSplitSize); err != nil {
+			return fmt.Errorf("invalid split size: %v", err)
+		}
+	}
+	fileOp.ArchiveName = archiveName
+	fileOp.CompressionLevel = archiveLevel
+	fileOp.Manifest = archiveManifest
+	return nil
+}
+
+// transferLimiter creates the limiter for --max-rate and --max-files
+func transferLimiter() (*search.TransferLimiter, error) {
+	var limits search.TransferLimits
+	if maxRate != "" {
+		rate, err := config.ParseSize(maxRate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate: %v", err)
+		}
+		limits.MaxBytesPerSecond = rate
+	}
+	limits.MaxConcurrentFiles = maxFiles
+	return search.NewTransferLimiter(limits), nil
+}
+
+// spaceErrorMessage describes a failed free space check with readable sizes
+func spaceErrorMessage(err error) string {
+	var spaceErr *search.SpaceError
+	if errors.As(err, &spaceErr) {
+		return fmt.Sprintf("Not enough space in %s: %s needed, %s available",
+			spaceErr.Dir, formatSize(spaceErr.Required), formatSize(spaceErr.Available))
+	}
+	return err.Error()
+}
+
+// needsTarget reports whether an operation writes into a target directory
+func needsTarget(op search.FileOperation) bool {
+	return op != search.DeleteFiles && op != search.TrashFiles
+}
+
+// printOperationSummary prints the totals of a run followed by its failures
+func printOperationSummary(summary *search.OperationSummary, interrupted bool) {
+	done, skipped, failed, bytes := summary.Counts()
+	fmt.Printf("Processed %d, skipped %d, failed %d (%s)", done, skipped, failed, formatSize(bytes))
+	if interrupted {
+		fmt.Print(" before interruption")
+	}
+	fmt.Println()
+	for _, r := range summary.Failures() {
+		fmt.Fprintf(os.Stderr, "  %s: %v\n", r.Path, r.Err)
+	}
+}
+
+// newOpsCmd creates the ops subcommand with copy, move, archive, trash and delete
+func newOpsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ops",
+		Short: "Copy, move, archive, trash or delete the files found by a search",
+	}
+
+	cmd.AddCommand(
+		newOperationCmd("copy", "Copy found files to the target directory", search.CopyFiles),
+		newOperationCmd("move", "Move found files to the target directory", search.MoveFiles),
+		newOperationCmd("archive", "Pack found files into a zip or tar.gz in the target directory", search.ArchiveFiles),
+		newOperationCmd("trash", "Move found files to the trash", search.TrashFiles),
+		newOperationCmd("delete", "Permanently delete found files", search.DeleteFiles),
+		newRestoreCmd(),
+		newCleanCmd(),
+		newJournalCmd(),
+		newUndoCmd(),
+	)
+	return cmd
+}
+
+// newOperationCmd creates a subcommand that applies op to every found file
+func newOperationCmd(name, short string, op search.FileOperation) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name + " [directories...]",
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, format, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			fileOp := search.FileOperationOptions{Operation: op}
+			if needsTarget(op) {
+				if targetDir == "" {
+					fmt.Println("target directory is required (--target)")
+					os.Exit(exitError)
+				}
+				fileOp.TargetDir = targetDir
+				fileOp.Roots = opts.RootDirs
+				fileOp.PreserveStructure = preserveStructure
+				fileOp.FlattenPaths = flattenPaths
+				fileOp.Verify = verifyCopies
+				if fileOp.Limiter, err = transferLimiter(); err != nil {
+					fmt.Println(err)
+					os.Exit(exitError)
+				}
+				if fileOp.PreserveAttributes, err = parseAttributeFlags(preserveAttrs); err != nil {
+					fmt.Println(err)
+					os.Exit(exitError)
+				}
+				// Never pick up files that were already placed in the target
+				opts.ExcludeDirs = append(opts.ExcludeDirs, targetDir)
+				if fileOp.ConflictPolicy, err = parseConflictPolicy(conflictPolicy); err != nil {
+					fmt.Println(err)
+					os.Exit(exitError)
+				}
+			}
+			if op == search.ArchiveFiles {
+				if err := archiveOptions(&fileOp); err != nil {
+					fmt.Println(err)
+					os.Exit(exitError)
+				}
+			}
+
+			stopChan := newStopChan("Operation interrupted by user")
+			opts.StopChan = stopChan
+
+			paths := make([]string, 0)
+			for result := range search.Search(opts) {
+				if result.Error == nil {
+					paths = append(paths, result.Path)
+				}
+			}
+
+			if dryRun {
+				fileOp.DryRun = true
+				fileOp.Plan = search.NewOperationPlan()
+				for _, path := range paths {
+					if r := search.RunFileOperation(path, fileOp); r.Err != nil {
+						fmt.Fprintf(os.Stderr, "Cannot plan %s: %v\n", path, r.Err)
+					}
+				}
+				printPlan(os.Stdout, fileOp.Plan, format)
+				if err := search.CheckFreeSpace(paths, fileOp); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", spaceErrorMessage(err))
+				}
+				return
+			}
+
+			if err := search.CheckFreeSpace(paths, fileOp); err != nil {
+				fmt.Println(spaceErrorMessage(err))
+				os.Exit(exitError)
+			}
+
+			journal, err := openJournal()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+			fileOp.Journal = journal
+			closeJournal := func() {
+				recorded := !journal.Empty()
+				if err := journal.Close(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+				if recorded && op != search.DeleteFiles {
+					fmt.Printf("Journal %s (undo with: koe ops undo %s)\n", journal.ID(), journal.ID())
+				}
+			}
+
+			if op == search.ArchiveFiles {
+				// Entry names are relative to the search roots
+				if fileOp.Archiver, err = search.NewArchiver(fileOp, opts.RootDirs); err != nil {
+					fmt.Println(err)
+					os.Exit(exitError)
+				}
+			}
+			archiveFailed := false
+			closeArchive := func(summary *search.OperationSummary) {
+				if fileOp.Archiver == nil {
+					return
+				}
+				parts, err := fileOp.Archiver.Close()
+				for _, part := range parts {
+					fmt.Printf("Wrote %s\n", part)
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					archiveFailed = true
+				}
+				// Files of discarded parts were counted as done
+				for _, r := range fileOp.Archiver.Lost() {
+					summary.Add(r)
+				}
+			}
+
+			finish := func(summary *search.OperationSummary, interrupted bool) {
+				fmt.Println()
+				closeArchive(summary)
+				closeJournal()
+				printOperationSummary(summary, interrupted)
+				if interrupted || archiveFailed {
+					os.Exit(exitError)
+				}
+				if _, _, failed, _ := summary.Counts(); failed > 0 {
+					os.Exit(exitError)
+				}
+			}
+
+			// Interrupting cancels the files that have not started yet
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				select {
+				case <-stopChan:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			workers := opts.MaxWorkers
+			if op == search.ArchiveFiles {
+				workers = 1 // Keep archive entries in search order
+			}
+			bar := progressbar.Default(int64(len(paths)), name)
+			processor := search.NewFileOperationProcessor(search.ProcessorOptions{
+				Workers:  workers,
+				Context:  ctx,
+				OnResult: func(search.OperationResult) { bar.Add(1) },
+			})
+			if err := processor.Start(); err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+			for _, path := range paths {
+				processor.AddContext(ctx, path, fileOp, nil)
+			}
+			processor.Stop()
+			finish(processor.Summary(), ctx.Err() != nil)
+		},
+	}
+
+	if op == search.TrashFiles {
+		cmd.Long = `Move found files to the trash, from where "koe ops restore" brings them back.
+On Linux and the BSDs this is the freedesktop.org trash used by the desktop;
+on macOS files go to ~/.Trash. Windows is not supported yet: use
+"ops move --target" to set files aside, or "ops delete".`
+	}
+
+	addSearchFlags(cmd)
+	if needsTarget(op) {
+		cmd.Flags().StringVarP(&targetDir, "target", "t", "", "Target directory")
+		cmd.Flags().StringVar(&conflictPolicy, "conflict", "skip", "On file name conflict: skip, overwrite or rename")
+	}
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Only print what would be done")
+	if op == search.CopyFiles || op == search.MoveFiles {
+		cmd.Flags().BoolVar(&preserveStructure, "preserve-structure", false, "Recreate each file's path relative to its search root under the target")
+		cmd.Flags().BoolVar(&flattenPaths, "flatten", false, "Put all files in the target, naming them after their relative path (a__b__file.txt)")
+		cmd.MarkFlagsMutuallyExclusive("preserve-structure", "flatten")
+		cmd.Flags().BoolVar(&verifyCopies, "verify", false, "Read every copy back and compare its hash with the source; a failed move keeps the source")
+		cmd.Flags().StringSliceVar(&preserveAttrs, "preserve", nil, "Attributes to keep on copies: times, mode, owner, xattrs or all")
+		cmd.Flags().StringVar(&maxRate, "max-rate", "", "Limit the combined copy rate per second (e.g., 20MB)")
+		cmd.Flags().IntVar(&maxFiles, "max-files", 0, "Copy at most this many files at the same time (default: one per worker)")
+	}
+	if op == search.ArchiveFiles {
+		cmd.Flags().StringVar(&archiveFormat, "format", "zip", "Archive format: zip or tar.gz")
+		cmd.Flags().StringVar(&archiveName, "name", "", "Archive name without extension (default koe-<timestamp>)")
+		cmd.Flags().IntVar(&archiveLevel, "level", 0, "Compression level from 1 (fastest) to 9 (smallest)")
+		cmd.Flags().BoolVar(&archiveManifest, "manifest", false, "Add MANIFEST.txt with the hash and size of every file")
+		cmd.Flags().StringVar(&archiveSplitSize, "split-size", "", "Start a new archive part after this size (e.g., 100MB)")
+	}
+	return cmd
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// jsonPlanEntry is the JSON representation of a dry run entry
+type jsonPlanEntry struct {
+	Action string `json:"action"`
+	Source string `json:"source"`
+	Target string `json:"target,omitempty"`
+	Bytes  int64  `json:"bytes"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// printPlan writes a dry run plan in the selected output format
+func printPlan(w io.Writer, plan *search.OperationPlan, format string) {
+	entries := plan.Entries()
+	files, bytes, skipped := plan.Totals()
+
+	if format == config.OutputJSON {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			enc.Encode(jsonPlanEntry{
+				Action: e.Action.String(),
+				Source: e.Source,
+				Target: e.Target,
+				Bytes:  e.Bytes,
+				Reason: e.Reason,
+			})
+		}
+		return
+	}
+
+	for _, e := range entries {
+		switch {
+		case e.Action == search.PlanSkip:
+			fmt.Fprintf(w, "%-7s %10s  %s (%s)\n", e.Action, "", e.Source, e.Reason)
+		case e.Target != "":
+			fmt.Fprintf(w, "%-7s %10s  %s -> %s\n", e.Action, formatSize(e.Bytes), e.Source, e.Target)
+		default:
+			fmt.Fprintf(w, "%-7s %10s  %s\n", e.Action, formatSize(e.Bytes), e.Source)
+		}
+	}
+	fmt.Fprintf(w, "\nDry run: would process %d files (%s), skip %d\n", files, formatSize(bytes), skipped)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/search"
+)
+
+var restoreList bool
+
+// newRestoreCmd creates the ops restore subcommand for trashed files
+func newRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore [original paths...]",
+		Short: "Restore files from the trash to where they were",
+		Long: `Restore files that were moved to the trash, by their original path.
+When a path was trashed several times the most recent copy is restored.
+Use --list to show what the trash contains.
+
+The home trash and the .Trash-$UID (or .Trash/$UID) directories at the top of
+every mounted file system are searched; mounts are read from /proc/self/mounts,
+so elsewhere only the home trash is. Not available on macOS and Windows.`,
+		Example: `  koe ops restore --list
+  koe ops restore /home/me/docs/report.pdf`,
+		Run: func(cmd *cobra.Command, args []string) {
+			trashed, err := search.ListTrash()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			if restoreList {
+				for _, t := range trashed {
+					fmt.Printf("%s  %s\n", t.DeletionDate.Format("2006-01-02 15:04:05"), t.OriginalPath)
+				}
+				return
+			}
+			if len(args) == 0 {
+				fmt.Println("at least one original path is required (or --list)")
+				os.Exit(exitError)
+			}
+
+			policy, err := parseConflictPolicy(conflictPolicy)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			failed := 0
+			for _, arg := range args {
+				path, err := filepath.Abs(arg)
+				if err != nil {
+					path = arg
+				}
+
+				// Entries are oldest first, so the last match is the newest
+				found := -1
+				for i, t := range trashed {
+					if t.OriginalPath == path {
+						found = i
+					}
+				}
+				if found < 0 {
+					fmt.Fprintf(os.Stderr, "Not in the trash: %s\n", path)
+					failed++
+					continue
+				}
+
+				restored, err := search.RestoreTrashed(trashed[found], policy)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					failed++
+					continue
+				}
+				trashed = append(trashed[:found], trashed[found+1:]...)
+				fmt.Printf("Restored %s\n", restored)
+			}
+			if failed > 0 {
+				os.Exit(exitError)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&restoreList, "list", false, "List the files in the trash")
+	cmd.Flags().StringVar(&conflictPolicy, "conflict", "skip", "If the original path exists: skip, overwrite or rename")
+	return cmd
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// jsonResult is the JSON representation of a found file
+type jsonResult struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+	UID     int    `json:"uid"`
+	GID     int    `json:"gid"`
+}
+
+// newSearchCmd creates the search subcommand
+func newSearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search [directories...]",
+		Short: "Search for files by name, extension, size and age",
+		Example: `  koe search -p report -e pdf -i /home
+  koe search --profile logs --output json`,
+		Run: runSearch,
+	}
+	addSearchFlags(cmd)
+	addResultFlags(cmd)
+	return cmd
+}
+
+// addResultFlags registers flags that control how search results are shown
+func addResultFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&showSize, "size", "s", true, "Show file sizes")
+	cmd.Flags().BoolVarP(&openInExplorer, "open", "o", false, "Open file location in explorer (when single file found)")
+	cmd.Flags().BoolVar(&listErrors, "errors", false, "List paths that could not be read")
+	cmd.Flags().BoolVar(&showSummary, "summary", false, "Print totals per root, extension and subdirectory when done")
+}
+
+// toJSONResult converts a search result for JSON output
+func toJSONResult(r search.SearchResult) jsonResult {
+	return jsonResult{
+		Path:    r.Path,
+		Size:    r.Size,
+		ModTime: r.ModTime.Format(time.RFC3339),
+		UID:     r.UID,
+		GID:     r.GID,
+	}
+}
+
+// printResult writes a single found file in the selected output format
+func printResult(w io.Writer, result search.SearchResult, format string) {
+	if format == config.OutputJSON {
+		json.NewEncoder(w).Encode(toJSONResult(result))
+		return
+	}
+
+	sizeStr := ""
+	if showSize {
+		sizeStr = fmt.Sprintf(" (%s)", formatSize(result.Size))
+	}
+	fmt.Fprintf(w, "\nFound: %s%s\n", result.Path, sizeStr)
+}
+
+// formatErrorKinds lists error counts per kind, e.g. "2 permission denied, 1 vanished"
+func formatErrorKinds(kinds map[search.ErrorKind]int) string {
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range []search.ErrorKind{
+		search.ErrPermissionDenied,
+		search.ErrVanished,
+		search.ErrIO,
+		search.ErrPathTooLong,
+		search.ErrOther,
+	} {
+		if n := kinds[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeProgress keeps the progress bar description up to date with
+// search counters until done is closed
+func describeProgress(bar *progressbar.ProgressBar, progress *search.SearchProgress, done chan struct{}) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s := progress.Snapshot()
+			if s.Done {
+				return
+			}
+			desc := fmt.Sprintf("Searching: %d dirs, %d files examined, %.0f files/s",
+				s.DirsVisited, s.FilesExamined, s.FilesPerSecond)
+			if eta := s.ETA(); eta > 0 {
+				desc += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+			}
+			bar.Describe(desc)
+		}
+	}
+}
+
+// runSearch performs a search, prints the results and exits with a
+// grep-style status
+func runSearch(cmd *cobra.Command, args []string) {
+	opts, format, err := buildSearchOptions(cmd, args)
+	if err == nil {
+		err = requireRoots(opts)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitError)
+	}
+
+	stopChan := newStopChan("Search interrupted by user")
+	opts.StopChan = stopChan
+	opts.ReportErrors = true
+	opts.Progress = search.NewSearchProgress()
+	if showSummary {
+		opts.Summary = search.NewSummaryCollector(search.DefaultSummaryTopN)
+	}
+
+	results := search.Search(opts)
+
+	bar := progressbar.Default(-1, "Searching")
+	done := make(chan struct{})
+	go describeProgress(bar, opts.Progress, done)
+
+	count := 0
+	foundFiles := make([]string, 0)
+	failed := make([]search.SearchResult, 0)
+	errorKinds := make(map[search.ErrorKind]int)
+
+	// Process search results
+	for result := range results {
+		select {
+		case <-stopChan:
+			os.Exit(exitError)
+		default:
+			bar.Add(1)
+			if result.Error != nil {
+				failed = append(failed, result)
+				errorKinds[search.ErrorKindOf(result.Error)]++
+				continue
+			}
+
+			printResult(os.Stdout, result, format)
+			foundFiles = append(foundFiles, result.Path)
+			count++
+		}
+	}
+	close(done)
+
+	// Keep stdout machine-readable in JSON mode
+	summary := os.Stdout
+	if format == config.OutputJSON {
+		summary = os.Stderr
+	}
+
+	if listErrors && len(failed) > 0 {
+		fmt.Fprintln(os.Stderr, "\nUnreadable paths:")
+		for _, f := range failed {
+			fmt.Fprintf(os.Stderr, "  %s: %s (%v)\n", f.Path, search.ErrorKindOf(f.Error), errors.Unwrap(f.Error))
+		}
+	}
+
+	if opts.Summary != nil {
+		printSummary(os.Stdout, opts.Summary.Summary(), format)
+	}
+
+	stats := opts.Progress.Snapshot()
+	fmt.Fprintf(summary, "\nTotal files found: %d\n", count)
+	fmt.Fprintf(summary, "Scanned %d directories and %d files in %s\n",
+		stats.DirsVisited, stats.FilesExamined, stats.Elapsed.Round(time.Millisecond))
+	if len(failed) > 0 {
+		fmt.Fprintf(summary, "Errors: %d (%s)", len(failed), formatErrorKinds(errorKinds))
+		if !listErrors {
+			fmt.Fprint(summary, ", use --errors to list them")
+		}
+		fmt.Fprintln(summary)
+	}
+
+	// If only one file found and open in explorer option is enabled
+	if openInExplorer && len(foundFiles) > 0 {
+		fmt.Println("Opening file location...")
+		if err := openFileLocation(foundFiles[0]); err != nil {
+			fmt.Printf("Error opening file location: %v\n", err)
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		os.Exit(exitError)
+	case count == 0:
+		os.Exit(exitNotFound)
+	default:
+		os.Exit(exitFound)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+var serveAddr string
+
+// newServeCmd creates the serve subcommand
+func newServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve searches over HTTP as newline-delimited JSON",
+		Long: `Start an HTTP server with the following endpoints:
+
+  GET /search?root=DIR&pattern=P&ext=E&ignore_case=1
+      Streams found files as newline-delimited JSON. root, pattern and ext
+      can be repeated. Defaults come from the selected profile.
+  GET /version
+      Returns version information.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			base, _, err := buildSearchOptions(cmd, nil)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			// The engine keeps per-search global state, so searches run one at a time
+			var searchMu sync.Mutex
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]string{
+					"version":    search.Version,
+					"build_time": search.BuildTime,
+					"git_commit": search.GitCommit,
+				})
+			})
+			mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				opts := base
+				query := r.URL.Query()
+				if roots := query["root"]; len(roots) > 0 {
+					opts.RootDirs = roots
+				}
+				if pats := query["pattern"]; len(pats) > 0 {
+					opts.Patterns = pats
+				}
+				if exts := query["ext"]; len(exts) > 0 {
+					opts.Extensions = exts
+				}
+				if ic := query.Get("ignore_case"); ic != "" {
+					opts.IgnoreCase = ic == "1" || strings.EqualFold(ic, "true")
+				}
+				if err := requireRoots(opts); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				searchMu.Lock()
+				defer searchMu.Unlock()
+
+				stopChan := make(chan struct{})
+				opts.StopChan = stopChan
+				results := search.Search(opts)
+
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				flusher, _ := w.(http.Flusher)
+				lastFlush := time.Now()
+
+				for result := range results {
+					select {
+					case <-r.Context().Done():
+						// Client went away: stop the search and drain remaining results
+						close(stopChan)
+						for range results {
+						}
+						return
+					default:
+					}
+
+					if result.Error != nil {
+						continue
+					}
+					printResult(w, result, config.OutputJSON)
+					if flusher != nil && time.Since(lastFlush) > 100*time.Millisecond {
+						flusher.Flush()
+						lastFlush = time.Now()
+					}
+				}
+			})
+
+			fmt.Printf("Listening on http://%s\n", serveAddr)
+			server := &http.Server{
+				Addr:              serveAddr,
+				Handler:           mux,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			if err := server.ListenAndServe(); err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7700", "Address to listen on")
+	return cmd
+}
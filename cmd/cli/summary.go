@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// jsonSummary is the JSON representation of a search summary
+type jsonSummary struct {
+	Files         int              `json:"files"`
+	TotalSize     int64            `json:"total_size"`
+	Roots         []jsonGroup      `json:"roots"`
+	TopDirs       []jsonGroup      `json:"top_dirs"`
+	Extensions    []jsonGroup      `json:"extensions"`
+	SizeHistogram []jsonSizeBucket `json:"size_histogram"`
+	Largest       []jsonResult     `json:"largest"`
+	Oldest        *jsonResult      `json:"oldest,omitempty"`
+	Newest        *jsonResult      `json:"newest,omitempty"`
+}
+
+// jsonGroup holds totals for a root, directory or extension
+type jsonGroup struct {
+	Name      string `json:"name"`
+	Files     int    `json:"files"`
+	TotalSize int64  `json:"total_size"`
+}
+
+// jsonSizeBucket is one size histogram bucket; max is 0 for the last bucket
+type jsonSizeBucket struct {
+	Min       int64 `json:"min"`
+	Max       int64 `json:"max"`
+	Files     int   `json:"files"`
+	TotalSize int64 `json:"total_size"`
+}
+
+// sortedGroups turns directory statistics into groups, largest first
+func sortedGroups(stats map[string]*search.DirStats) []jsonGroup {
+	groups := make([]jsonGroup, 0, len(stats))
+	for name, s := range stats {
+		groups = append(groups, jsonGroup{Name: name, Files: s.FileCount, TotalSize: s.TotalSize})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].TotalSize != groups[j].TotalSize {
+			return groups[i].TotalSize > groups[j].TotalSize
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// printSummary writes the search summary in the selected output format
+func printSummary(w io.Writer, s search.SearchSummary, format string) {
+	roots := sortedGroups(s.Roots)
+	topDirs := sortedGroups(s.TopDirs)
+
+	exts := make([]jsonGroup, len(s.Extensions))
+	for i, e := range s.Extensions {
+		name := e.Ext
+		if name == "" {
+			name = "(none)"
+		}
+		exts[i] = jsonGroup{Name: name, Files: e.Files, TotalSize: e.TotalSize}
+	}
+
+	if format == config.OutputJSON {
+		out := jsonSummary{
+			Files:      s.Files,
+			TotalSize:  s.TotalSize,
+			Roots:      roots,
+			TopDirs:    topDirs,
+			Extensions: exts,
+			Largest:    make([]jsonResult, len(s.Largest)),
+		}
+		for _, b := range s.SizeHistogram {
+			out.SizeHistogram = append(out.SizeHistogram, jsonSizeBucket(b))
+		}
+		for i, r := range s.Largest {
+			out.Largest[i] = toJSONResult(r)
+		}
+		if s.Files > 0 {
+			oldest, newest := toJSONResult(s.Oldest), toJSONResult(s.Newest)
+			out.Oldest, out.Newest = &oldest, &newest
+		}
+		json.NewEncoder(w).Encode(struct {
+			Summary jsonSummary `json:"summary"`
+		}{out})
+		return
+	}
+
+	fmt.Fprintf(w, "\nSummary: %d files, %s\n", s.Files, formatSize(s.TotalSize))
+	if s.Files == 0 {
+		return
+	}
+
+	printGroups := func(title string, groups []jsonGroup) {
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, g := range groups {
+			fmt.Fprintf(w, "  %10s %8d  %s\n", formatSize(g.TotalSize), g.Files, g.Name)
+		}
+	}
+	printGroups("By root", roots)
+	printGroups("By top-level directory", topDirs)
+	printGroups("By extension", exts)
+
+	fmt.Fprintln(w, "\nBy size:")
+	for _, b := range s.SizeHistogram {
+		label := fmt.Sprintf(">= %s", formatSize(b.Min))
+		if b.Max > 0 {
+			label = fmt.Sprintf("< %s", formatSize(b.Max))
+		}
+		fmt.Fprintf(w, "  %-12s %8d files  %s\n", label, b.Files, formatSize(b.TotalSize))
+	}
+
+	fmt.Fprintln(w, "\nLargest files:")
+	for _, r := range s.Largest {
+		fmt.Fprintf(w, "  %10s  %s\n", formatSize(r.Size), r.Path)
+	}
+
+	fmt.Fprintf(w, "\nOldest: %s (%s)\n", s.Oldest.Path, s.Oldest.ModTime.Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "Newest: %s (%s)\n", s.Newest.Path, s.Newest.ModTime.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/cmd/cli/tui"
+)
+
+// newTuiCmd creates the interactive terminal UI subcommand
+func newTuiCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui [directories...]",
+		Short: "Search interactively in the terminal",
+		Long: `Start a search and browse the results as they stream in.
+
+Type to filter the list, Tab to select files, Enter to print the selected
+paths and exit. Ctrl+O opens the file location, Ctrl+Y copies the paths to
+the terminal clipboard, Ctrl+T/Ctrl+R/Ctrl+D copy, move or delete the
+selected files.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, _, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			chosen, err := tui.Run(tui.Options{
+				Search: opts,
+				Open:   openFileLocation,
+			})
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			for _, path := range chosen {
+				fmt.Println(path)
+			}
+		},
+	}
+	addSearchFlags(cmd)
+	return cmd
+}
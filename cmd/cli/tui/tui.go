@@ -0,0 +1,621 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+
+	"filesearch/internal/search"
+)
+
+// Key bindings shown in the help line
+const helpText = "↑↓ move  Tab select  ^A all  Enter print&quit  ^O open  ^Y yank  ^T copy  ^R move  ^D delete  Esc clear  ^C quit"
+
+// Preview limits
+const (
+	previewBytes = 8 * 1024
+	redrawDelay  = 100 * time.Millisecond
+)
+
+// Options configures the terminal UI
+type Options struct {
+	Search search.SearchOptions
+	Open   func(path string) error // Opens the file location, may be nil
+}
+
+// promptKind identifies what the bottom-line prompt is asking for
+type promptKind int
+
+const (
+	noPrompt promptKind = iota
+	targetPrompt
+	confirmPrompt
+)
+
+// model holds the UI state
+type model struct {
+	screen tcell.Screen
+	opts   Options
+
+	mu        sync.Mutex
+	results   []search.SearchResult
+	searching bool
+	opStatus  string
+	opRunning bool
+
+	filter   []rune
+	filtered []int // Indices into results that match the filter
+	scanned  int   // Number of results already checked against the filter
+	cursor   int
+	offset   int
+	selected map[string]bool
+
+	prompt     promptKind
+	promptText []rune
+	pendingOp  search.FileOperation
+	message    string
+
+	previewPath  string
+	previewLines []string
+}
+
+// Run starts the search and the interactive UI. It returns the paths chosen
+// with Enter (empty if the user quit).
+func Run(opts Options) ([]string, error) {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create screen: %v", err)
+	}
+	if err := screen.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize screen: %v", err)
+	}
+	defer screen.Fini()
+
+	m := &model{
+		screen:    screen,
+		opts:      opts,
+		searching: true,
+		selected:  make(map[string]bool),
+	}
+
+	stopChan := make(chan struct{})
+	m.opts.Search.StopChan = stopChan
+	go m.collect(search.Search(m.opts.Search))
+
+	var chosen []string
+	quit := false
+	for !quit {
+		m.refilter()
+		m.draw()
+
+		switch ev := screen.PollEvent().(type) {
+		case *tcell.EventResize:
+			screen.Sync()
+		case *tcell.EventKey:
+			quit, chosen = m.handleKey(ev)
+		}
+	}
+
+	close(stopChan)
+	return chosen, nil
+}
+
+// collect appends streamed search results and schedules redraws
+func (m *model) collect(results chan search.SearchResult) {
+	lastRedraw := time.Now()
+	for result := range results {
+		if result.Error != nil {
+			continue
+		}
+		m.mu.Lock()
+		m.results = append(m.results, result)
+		m.mu.Unlock()
+
+		if time.Since(lastRedraw) > redrawDelay {
+			m.screen.PostEvent(tcell.NewEventInterrupt(nil))
+			lastRedraw = time.Now()
+		}
+	}
+
+	m.mu.Lock()
+	m.searching = false
+	m.mu.Unlock()
+	m.screen.PostEvent(tcell.NewEventInterrupt(nil))
+}
+
+// refilter matches results that arrived since the last call against the filter
+func (m *model) refilter() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	needle := strings.ToLower(string(m.filter))
+	for ; m.scanned < len(m.results); m.scanned++ {
+		if needle == "" || strings.Contains(strings.ToLower(m.results[m.scanned].Path), needle) {
+			m.filtered = append(m.filtered, m.scanned)
+		}
+	}
+
+	if m.cursor >= len(m.filtered) {
+		m.cursor = len(m.filtered) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// resetFilter forces all results to be matched again against a changed filter
+func (m *model) resetFilter() {
+	m.mu.Lock()
+	m.filtered = m.filtered[:0]
+	m.scanned = 0
+	m.mu.Unlock()
+	m.cursor = 0
+	m.offset = 0
+}
+
+// current returns the result under the cursor
+func (m *model) current() (search.SearchResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return search.SearchResult{}, false
+	}
+	return m.results[m.filtered[m.cursor]], true
+}
+
+// targets returns the selected paths, or the path under the cursor if none are selected
+func (m *model) targets() []string {
+	if len(m.selected) > 0 {
+		paths := make([]string, 0, len(m.selected))
+		m.mu.Lock()
+		for _, r := range m.results {
+			if m.selected[r.Path] {
+				paths = append(paths, r.Path)
+			}
+		}
+		m.mu.Unlock()
+		return paths
+	}
+	if r, ok := m.current(); ok {
+		return []string{r.Path}
+	}
+	return nil
+}
+
+// handleKey processes a key press and reports whether the UI should exit
+func (m *model) handleKey(ev *tcell.EventKey) (bool, []string) {
+	if m.prompt != noPrompt {
+		m.handlePromptKey(ev)
+		return false, nil
+	}
+
+	m.message = ""
+	m.mu.Lock()
+	count := len(m.filtered)
+	m.mu.Unlock()
+	_, height := m.screen.Size()
+	page := height - 4
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC, tcell.KeyCtrlQ:
+		return true, nil
+	case tcell.KeyEnter:
+		return true, m.targets()
+	case tcell.KeyUp:
+		m.cursor--
+	case tcell.KeyDown:
+		m.cursor++
+	case tcell.KeyPgUp:
+		m.cursor -= page
+	case tcell.KeyPgDn:
+		m.cursor += page
+	case tcell.KeyHome:
+		m.cursor = 0
+	case tcell.KeyEnd:
+		m.cursor = count - 1
+	case tcell.KeyTab:
+		if r, ok := m.current(); ok {
+			if m.selected[r.Path] {
+				delete(m.selected, r.Path)
+			} else {
+				m.selected[r.Path] = true
+			}
+			m.cursor++
+		}
+	case tcell.KeyCtrlA:
+		m.mu.Lock()
+		for _, i := range m.filtered {
+			m.selected[m.results[i].Path] = true
+		}
+		m.mu.Unlock()
+	case tcell.KeyCtrlO:
+		if r, ok := m.current(); ok && m.opts.Open != nil {
+			if err := m.opts.Open(r.Path); err != nil {
+				m.message = fmt.Sprintf("Open failed: %v", err)
+			}
+		}
+	case tcell.KeyCtrlY:
+		m.yank(m.targets())
+	case tcell.KeyCtrlT:
+		m.startPrompt(targetPrompt, search.CopyFiles)
+	case tcell.KeyCtrlR:
+		m.startPrompt(targetPrompt, search.MoveFiles)
+	case tcell.KeyCtrlD:
+		m.startPrompt(confirmPrompt, search.DeleteFiles)
+	case tcell.KeyEscape:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:0]
+			m.resetFilter()
+		} else {
+			m.selected = make(map[string]bool)
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+			m.resetFilter()
+		}
+	case tcell.KeyRune:
+		m.filter = append(m.filter, ev.Rune())
+		m.resetFilter()
+	}
+
+	if m.cursor >= count {
+		m.cursor = count - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	return false, nil
+}
+
+// startPrompt asks for a target directory or a delete confirmation
+func (m *model) startPrompt(kind promptKind, op search.FileOperation) {
+	m.mu.Lock()
+	running := m.opRunning
+	m.mu.Unlock()
+	if running {
+		m.message = "An operation is already running"
+		return
+	}
+	if len(m.targets()) == 0 {
+		m.message = "Nothing selected"
+		return
+	}
+	m.prompt = kind
+	m.pendingOp = op
+	m.promptText = m.promptText[:0]
+}
+
+// handlePromptKey edits the prompt line and starts the operation on Enter
+func (m *model) handlePromptKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		m.prompt = noPrompt
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(m.promptText) > 0 {
+			m.promptText = m.promptText[:len(m.promptText)-1]
+		}
+	case tcell.KeyRune:
+		m.promptText = append(m.promptText, ev.Rune())
+	case tcell.KeyEnter:
+		kind := m.prompt
+		m.prompt = noPrompt
+		fileOp := search.FileOperationOptions{
+			Operation:      m.pendingOp,
+			ConflictPolicy: search.Rename,
+		}
+		if kind == confirmPrompt {
+			if strings.ToLower(string(m.promptText)) != "y" {
+				m.message = "Delete cancelled"
+				return
+			}
+		} else {
+			if len(m.promptText) == 0 {
+				m.message = "No target directory given"
+				return
+			}
+			fileOp.TargetDir = string(m.promptText)
+		}
+
+		// Mark the operation as running before it starts so that another
+		// prompt cannot start a second one on the same selection
+		m.mu.Lock()
+		running := m.opRunning
+		m.opRunning = true
+		m.mu.Unlock()
+		if running {
+			m.message = "An operation is already running"
+			return
+		}
+		go m.runOperation(m.targets(), fileOp)
+	}
+}
+
+// runOperation applies a file operation to paths in the background. The
+// caller sets opRunning; it is cleared when the operation is done.
+func (m *model) runOperation(paths []string, fileOp search.FileOperationOptions) {
+	name := operationName(fileOp.Operation)
+	done, failed := 0, 0
+	var lastErr error
+	removed := make(map[string]bool)
+
+	// Journal every operation so it can be undone with "koe ops undo"
+	dir, err := search.DefaultJournalDir()
+	if err == nil {
+		fileOp.Journal, err = search.NewJournal(dir)
+	}
+	if err != nil {
+		m.mu.Lock()
+		m.opRunning = false
+		m.opStatus = fmt.Sprintf("%s: failed to create operation journal: %v", name, err)
+		m.mu.Unlock()
+		m.screen.PostEvent(tcell.NewEventInterrupt(nil))
+		return
+	}
+
+	for i, path := range paths {
+		if err := search.HandleFileOperation(path, fileOp); err != nil {
+			search.LogError("Failed to %s %s: %v", name, path, err)
+			failed++
+			lastErr = err
+		} else {
+			done++
+			if fileOp.Operation != search.CopyFiles {
+				removed[path] = true
+			}
+		}
+
+		m.mu.Lock()
+		m.opStatus = fmt.Sprintf("%s: %d/%d", name, i+1, len(paths))
+		m.mu.Unlock()
+		m.screen.PostEvent(tcell.NewEventInterrupt(nil))
+	}
+
+	recorded := !fileOp.Journal.Empty()
+	if err := fileOp.Journal.Close(); err != nil {
+		search.LogError("Failed to close journal: %v", err)
+	}
+
+	m.mu.Lock()
+	m.opRunning = false
+	m.opStatus = fmt.Sprintf("%s: %d done, %d failed", name, done, failed)
+	if lastErr != nil {
+		m.opStatus += fmt.Sprintf(" (last error: %v)", lastErr)
+	}
+	if recorded && fileOp.Operation != search.DeleteFiles {
+		m.opStatus += fmt.Sprintf(" (undo with: koe ops undo %s)", fileOp.Journal.ID())
+	}
+	if len(removed) > 0 {
+		kept := m.results[:0]
+		for _, r := range m.results {
+			if !removed[r.Path] {
+				kept = append(kept, r)
+			}
+		}
+		m.results = kept
+		m.filtered = m.filtered[:0]
+		m.scanned = 0
+	}
+	m.mu.Unlock()
+
+	m.screen.PostEvent(tcell.NewEventInterrupt(nil))
+}
+
+// yank copies paths to the terminal clipboard using the OSC 52 sequence,
+// which also works over SSH in terminals that support it
+func (m *model) yank(paths []string) {
+	if len(paths) == 0 {
+		m.message = "Nothing selected"
+		return
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		m.message = "Clipboard is not available; use Enter to print paths"
+		return
+	}
+	defer tty.Close()
+
+	data := base64.StdEncoding.EncodeToString([]byte(strings.Join(paths, "\n")))
+	fmt.Fprintf(tty, "\x1b]52;c;%s\x07", data)
+	m.message = fmt.Sprintf("Copied %d path(s) to clipboard", len(paths))
+}
+
+// draw renders the whole screen
+func (m *model) draw() {
+	s := m.screen
+	s.Clear()
+	width, height := s.Size()
+	if width < 20 || height < 6 {
+		s.Show()
+		return
+	}
+
+	normal := tcell.StyleDefault
+	dim := normal.Foreground(tcell.ColorGray)
+	highlight := normal.Reverse(true)
+	marked := normal.Foreground(tcell.ColorYellow)
+
+	m.mu.Lock()
+	total := len(m.results)
+	shown := len(m.filtered)
+	searching := m.searching
+	opStatus := m.opStatus
+	m.mu.Unlock()
+
+	// Filter line
+	drawText(s, 0, 0, width, normal.Bold(true), "Filter: ")
+	drawText(s, 8, 0, width-8, normal, string(m.filter))
+	if m.prompt == noPrompt {
+		s.ShowCursor(8+runewidth.StringWidth(string(m.filter)), 0)
+	}
+
+	// Result list and preview split
+	listWidth := width * 3 / 5
+	listHeight := height - 3
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+listHeight {
+		m.offset = m.cursor - listHeight + 1
+	}
+
+	m.mu.Lock()
+	for row := 0; row < listHeight; row++ {
+		i := m.offset + row
+		if i >= len(m.filtered) {
+			break
+		}
+		r := m.results[m.filtered[i]]
+		style := normal
+		mark := "  "
+		if m.selected[r.Path] {
+			style = marked
+			mark = "* "
+		}
+		if i == m.cursor {
+			style = style.Reverse(true)
+		}
+		drawText(s, 0, row+1, listWidth-1, style, mark+r.Path)
+	}
+	m.mu.Unlock()
+
+	for y := 1; y <= listHeight; y++ {
+		s.SetContent(listWidth-1, y, '│', nil, dim)
+	}
+	m.drawPreview(listWidth, 1, width-listWidth, listHeight)
+
+	// Status line
+	state := "done"
+	if searching {
+		state = "searching…"
+	}
+	status := fmt.Sprintf(" %d found, %d shown, %d selected | %s", total, shown, len(m.selected), state)
+	if opStatus != "" {
+		status += " | " + opStatus
+	}
+	if m.message != "" {
+		status += " | " + m.message
+	}
+	drawText(s, 0, height-2, width, highlight, padRight(status, width))
+
+	// Help or prompt line
+	switch m.prompt {
+	case targetPrompt:
+		label := fmt.Sprintf("%s to directory: ", operationName(m.pendingOp))
+		drawText(s, 0, height-1, width, normal.Bold(true), label)
+		drawText(s, len(label), height-1, width-len(label), normal, string(m.promptText))
+		s.ShowCursor(len(label)+runewidth.StringWidth(string(m.promptText)), height-1)
+	case confirmPrompt:
+		label := fmt.Sprintf("Permanently delete %d file(s)? [y/N]: ", len(m.targets()))
+		drawText(s, 0, height-1, width, normal.Bold(true), label)
+		drawText(s, len(label), height-1, width-len(label), normal, string(m.promptText))
+		s.ShowCursor(len(label)+len(m.promptText), height-1)
+	default:
+		drawText(s, 0, height-1, width, dim, helpText)
+	}
+
+	s.Show()
+}
+
+// drawPreview shows metadata and the beginning of the file under the cursor
+func (m *model) drawPreview(x, y, width, height int) {
+	r, ok := m.current()
+	if !ok || width < 10 {
+		return
+	}
+
+	if r.Path != m.previewPath {
+		m.previewPath = r.Path
+		m.previewLines = loadPreview(r.Path)
+	}
+
+	dim := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	header := []string{
+		filepath.Base(r.Path),
+		fmt.Sprintf("%d bytes, %s", r.Size, r.ModTime.Format("2006-01-02 15:04")),
+		"",
+	}
+	row := 0
+	for _, line := range header {
+		drawText(m.screen, x+1, y+row, width-1, dim, line)
+		row++
+	}
+	for _, line := range m.previewLines {
+		if row >= height {
+			break
+		}
+		drawText(m.screen, x+1, y+row, width-1, tcell.StyleDefault, line)
+		row++
+	}
+}
+
+// loadPreview reads the start of a file and splits it into display lines
+func loadPreview(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return []string{fmt.Sprintf("(cannot read: %v)", err)}
+	}
+	defer f.Close()
+
+	buf := make([]byte, previewBytes)
+	n, _ := f.Read(buf)
+	buf = buf[:n]
+
+	if bytes.IndexByte(buf, 0) >= 0 || !utf8.Valid(buf) {
+		return []string{"(binary file)"}
+	}
+
+	text := strings.ReplaceAll(string(buf), "\t", "    ")
+	return strings.Split(text, "\n")
+}
+
+// drawText writes text at (x, y), truncated to maxWidth cells
+func drawText(s tcell.Screen, x, y, maxWidth int, style tcell.Style, text string) {
+	col := 0
+	for _, r := range text {
+		if r == '\r' {
+			continue
+		}
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			w = 1
+		}
+		if col+w > maxWidth {
+			break
+		}
+		s.SetContent(x+col, y, r, nil, style)
+		col += w
+	}
+}
+
+// padRight pads text with spaces to width cells
+func padRight(text string, width int) string {
+	if pad := width - runewidth.StringWidth(text); pad > 0 {
+		return text + strings.Repeat(" ", pad)
+	}
+	return text
+}
+
+// operationName returns a short verb for a file operation
+func operationName(op search.FileOperation) string {
+	switch op {
+	case search.CopyFiles:
+		return "copy"
+	case search.MoveFiles:
+		return "move"
+	case search.DeleteFiles:
+		return "delete"
+	default:
+		return "operation"
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// jsonWatchEvent is the JSON representation of a watch event
+type jsonWatchEvent struct {
+	Path string `json:"path"`
+	Op   string `json:"op"`
+	Time string `json:"time"`
+}
+
+// newWatchCmd creates the watch subcommand
+func newWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [directories...]",
+		Short: "Report changes to matching files as they happen",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts, format, err := buildSearchOptions(cmd, args)
+			if err == nil {
+				err = requireRoots(opts)
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			opts.StopChan = newStopChan("Watch stopped by user")
+			events, err := search.Watch(opts)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(exitError)
+			}
+
+			encoder := json.NewEncoder(os.Stdout)
+			for event := range events {
+				if event.Error != nil {
+					fmt.Fprintf(os.Stderr, "Watch error: %v\n", event.Error)
+					continue
+				}
+				if format == config.OutputJSON {
+					encoder.Encode(jsonWatchEvent{
+						Path: event.Path,
+						Op:   event.Op,
+						Time: event.Time.Format(time.RFC3339),
+					})
+					continue
+				}
+				fmt.Printf("%s %-6s %s\n", event.Time.Format("15:04:05"), event.Op, event.Path)
+			}
+		},
+	}
+	addSearchFlags(cmd)
+	return cmd
+}
@@ -86,6 +86,9 @@ func main() {
 	// Create search panel
 	searchPanel := ui.CreateSearchPanel(w)
 	
+	// Create profile panel (applies config defaults to the search panel)
+	profilePanel := ui.CreateProfilePanel(w, searchPanel)
+	
 	// Create progress bar
 	progress := widget.NewProgressBarInfinite()
 	progress.Hide()
@@ -156,14 +159,24 @@ func main() {
 	)
 	fileOpAccordion.Close(0) // Closed by default
 	
+	// Create results summary accordion
+	summaryPanel := ui.CreateSummaryPanel()
+	summaryAccordion := widget.NewAccordion(
+		widget.NewAccordionItem("Results Summary", summaryPanel.GetContent()),
+	)
+	
 	// Layout
 	inputs := container.NewVBox(
 		searchPanel.GetContent(),
 		widget.NewSeparator(),
+		profilePanel.GetContent(),
+		widget.NewSeparator(),
 		fileOpAccordion,
+		summaryAccordion,
 		widget.NewSeparator(),
 		searchTimeLabel,
-		fileOpPanel.OperationBtn,
+		container.NewGridWithColumns(2, fileOpPanel.PreviewBtn, fileOpPanel.OperationBtn),
+		fileOpPanel.UndoBtn,
 		progress,
 		widget.NewSeparator(),
 		aboutBox,
@@ -235,8 +248,11 @@ func main() {
 			Extensions:  utils.SplitCommaList(searchPanel.ExtensionEntry.Text),
 			MaxWorkers:  runtime.NumCPU(),
 			IgnoreCase:  searchPanel.IgnoreCaseCheck.Checked,
+			SearchArchives: searchPanel.ArchivesCheck.Checked,
 			BufferSize:  2000,  // Increased buffer size
 			StopChan:    stopChan,
+			Progress:    search.NewSearchProgress(),
+			Summary:     search.NewSummaryCollector(search.DefaultSummaryTopN),
 			
 			// All performance-impacting features are disabled
 			DeduplicateFiles: false,
@@ -250,10 +266,24 @@ func main() {
 		}
 
 		// If target directory is set, add it to excluded directories
+		fileOpPanel.SearchRoots = searchDirs
 		if fileOpPanel.TargetDir != "" {
 			opts.ExcludeDirs = append(opts.ExcludeDirs, fileOpPanel.TargetDir)
 		}
 		
+		// Apply profile settings that have no widgets (excludes, size/age filters)
+		if err := profilePanel.ApplyTo(&opts); err != nil {
+			search.LogError("Invalid profile settings: %v", err)
+			searchBtn.Enable()
+			if len(*foundFiles) > 0 {
+				fileOpPanel.Enable()
+			}
+			progress.Hide()
+			searchTimeLabel.SetText("")
+			dialog.ShowError(fmt.Errorf("Invalid profile settings: %v", err), w)
+			return
+		}
+		
 		search.LogInfo("Starting search with options: %+v", opts)
 		
 		// Clear previous results
@@ -303,6 +333,7 @@ func main() {
 							fileOpPanel.Enable()
 						}
 						progress.Hide()
+						summaryPanel.SetSummary(opts.Summary.Summary())
 						
 						// Calculate and display search time
 						duration := time.Since(startTime)
@@ -334,14 +365,29 @@ func main() {
 						resultsBuffer.Flush()
 						lastUpdate = time.Now()
 						
-						// Update search time
+						// Update search time and progress counters
 						duration := time.Since(startTime)
 						seconds := int(duration.Seconds())
 						milliseconds := int(duration.Milliseconds()) % 1000
-						searchTimeLabel.SetText(fmt.Sprintf("Searching... %d.%03d seconds\n(%d files found)",
+						stats := opts.Progress.Snapshot()
+						status := fmt.Sprintf("Searching... %d.%03d seconds\n(%d files found)\nScanned %d dirs, %d files (%.0f files/s)",
 							seconds,
 							milliseconds,
-							count))
+							count,
+							stats.DirsVisited,
+							stats.FilesExamined,
+							stats.FilesPerSecond)
+						if eta := stats.ETA(); eta > 0 {
+							status += fmt.Sprintf("\nAbout %s left", eta.Round(time.Second))
+						}
+						if dir := stats.CurrentDir; dir != "" {
+							// Keep the label narrow enough for the side panel
+							if len(dir) > 40 {
+								dir = "..." + dir[len(dir)-37:]
+							}
+							status += "\n" + dir
+						}
+						searchTimeLabel.SetText(status)
 					}
 					
 				case <-stopChan:
@@ -352,6 +398,7 @@ func main() {
 						fileOpPanel.Enable()
 					}
 					progress.Hide()
+					summaryPanel.SetSummary(opts.Summary.Summary())
 					
 					// Calculate and display final search time
 					duration := time.Since(startTime)
@@ -1,15 +1,18 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"filesearch/internal/config"
 	"filesearch/internal/search"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
-	"time"
 	"sync/atomic"
 )
 
@@ -19,32 +22,70 @@ type FileOperationsPanel struct {
 	TargetDirLabel  *widget.Label
 	SelectTargetBtn *widget.Button
 	ConflictPolicy  *widget.Select
+	LayoutSelect    *widget.Select
+	PreserveCheck   *widget.Check
+	VerifyCheck     *widget.Check
+	RateEntry       *widget.Entry  // Combined copy rate limit, e.g. 20MB
+	ParallelSelect  *widget.Select // Files copied at the same time
 	TargetDir       string
+	SearchRoots     []string // Roots of the last search, used for archive entry names
+	PreviewBtn      *widget.Button // Shows a dry run of the operation
 	OperationBtn    *widget.Button
+	UndoBtn         *widget.Button // Reverses the last journaled operation
+	lastJournal     string
 	mu              sync.Mutex // Mutex for foundFiles protection
 }
 
 // CreateFileOperationsPanel creates and returns file operations panel widgets
 func CreateFileOperationsPanel(window fyne.Window, foundFiles *[]FileListItem) *FileOperationsPanel {
+	// Trash is the default way to remove files; permanent deletion is kept
+	// apart at the end of the list
+	operations := []string{"No Operation"}
+	defaultOperation := "No Operation"
+	if search.TrashSupported {
+		operations = append(operations, "Move to Trash")
+		defaultOperation = "Move to Trash"
+	}
+	operations = append(operations,
+		"Copy Files",
+		"Move Files",
+		"Archive Files (zip)",
+		"Archive Files (tar.gz)",
+		"Delete Permanently",
+	)
+
 	panel := &FileOperationsPanel{
-		OpTypeSelect: widget.NewSelect([]string{
-			"No Operation",
-			"Copy Files",
-			"Move Files",
-			"Delete Files",
-		}, nil),
+		OpTypeSelect:   widget.NewSelect(operations, nil),
 		TargetDirLabel: widget.NewLabel("Target Directory: "),
 		ConflictPolicy: widget.NewSelect([]string{
 			"Skip",
 			"Overwrite",
 			"Rename",
 		}, nil),
+		LayoutSelect: widget.NewSelect([]string{
+			"File Names Only",
+			"Keep Folder Structure",
+			"Path in File Name",
+		}, nil),
+		PreserveCheck: widget.NewCheck("Keep timestamps, permissions, owner and xattrs", nil),
+		VerifyCheck:   widget.NewCheck("Verify copies with checksums", nil),
+		RateEntry:     widget.NewEntry(),
+		ParallelSelect: widget.NewSelect([]string{
+			"Auto",
+			"1",
+			"2",
+			"4",
+			"8",
+		}, nil),
 		OperationBtn: widget.NewButton("Apply Operation", nil),
 	}
 	
 	panel.TargetDirLabel.Wrapping = fyne.TextWrapWord
-	panel.OpTypeSelect.SetSelected("No Operation")
+	panel.OpTypeSelect.SetSelected(defaultOperation)
 	panel.ConflictPolicy.SetSelected("Skip")
+	panel.LayoutSelect.SetSelected("File Names Only")
+	panel.RateEntry.SetPlaceHolder("Unlimited (e.g., 20MB)")
+	panel.ParallelSelect.SetSelected("Auto")
 	
 	panel.SelectTargetBtn = widget.NewButton("Select Target Directory", func() {
 		d := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
@@ -61,42 +102,55 @@ func CreateFileOperationsPanel(window fyne.Window, foundFiles *[]FileListItem) *
 		d.Show()
 	})
 	
+	panel.PreviewBtn = widget.NewButton("Preview", func() {
+		panel.preview(window, foundFiles)
+	})
+
+	var run func(fileOp search.FileOperationOptions)
 	panel.OperationBtn.OnTapped = func() {
 		if len(*foundFiles) == 0 {
 			dialog.ShowError(fmt.Errorf("No files found to process"), window)
 			return
 		}
 
-		// Validate file operations
-		var fileOp search.FileOperationOptions
-		switch panel.OpTypeSelect.Selected {
-		case "Copy Files":
-			fileOp.Operation = search.CopyFiles
-		case "Move Files":
-			fileOp.Operation = search.MoveFiles
-		case "Delete Files":
-			fileOp.Operation = search.DeleteFiles
-		default:
-			dialog.ShowError(fmt.Errorf("Please select an operation"), window)
+		fileOp, err := panel.fileOperation()
+		if err != nil {
+			dialog.ShowError(err, window)
 			return
 		}
 
-		if fileOp.Operation != search.NoOperation && fileOp.Operation != search.DeleteFiles {
-			if panel.TargetDir == "" {
-				dialog.ShowError(fmt.Errorf("Please select target directory"), window)
-				return
-			}
+		// Permanent deletion cannot be undone, so ask first
+		if fileOp.Operation == search.DeleteFiles {
+			dialog.ShowConfirm("Delete Permanently",
+				fmt.Sprintf("Permanently delete %d files? They cannot be restored.\nUse Move to Trash to keep a way back.", len(*foundFiles)),
+				func(ok bool) {
+					if ok {
+						run(fileOp)
+					}
+				}, window)
+			return
 		}
+		run(fileOp)
+	}
 
-		fileOp.TargetDir = panel.TargetDir
+	run = func(fileOp search.FileOperationOptions) {
+		// Journal every operation so it can be undone
+		dir, err := search.DefaultJournalDir()
+		if err == nil {
+			fileOp.Journal, err = search.NewJournal(dir)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Failed to create operation journal: %v", err), window)
+			return
+		}
 
-		switch panel.ConflictPolicy.Selected {
-		case "Skip":
-			fileOp.ConflictPolicy = search.Skip
-		case "Overwrite":
-			fileOp.ConflictPolicy = search.Overwrite
-		case "Rename":
-			fileOp.ConflictPolicy = search.Rename
+		if fileOp.Operation == search.ArchiveFiles {
+			archiver, err := search.NewArchiver(fileOp, panel.SearchRoots)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			fileOp.Archiver = archiver
 		}
 
 		// Create progress dialog
@@ -105,46 +159,85 @@ func CreateFileOperationsPanel(window fyne.Window, foundFiles *[]FileListItem) *
 
 		// Process files in a goroutine
 		go func() {
-			processor := search.NewFileOperationProcessor(search.ProcessorOptions{
-				Workers:          runtime.NumCPU(),
-				MaxQueueSize:     1000,
-				ThrottleInterval: 100 * time.Millisecond,
-			})
-			if err := processor.Start(); err != nil {
-				dialog.ShowError(fmt.Errorf("Failed to start processor: %v", err), window)
-				return
-			}
-			defer processor.Stop()
-
 			panel.mu.Lock()
 			filesToProcess := make([]FileListItem, len(*foundFiles))
 			copy(filesToProcess, *foundFiles)
 			total := len(filesToProcess)
 			panel.mu.Unlock()
 
-			var processed int32
+			paths := make([]string, total)
 			for i, file := range filesToProcess {
-				if progress != nil {
-					progress.SetValue(float64(i) / float64(total))
+				paths[i] = file.Path
+			}
+			if err := search.CheckFreeSpace(paths, fileOp); err != nil {
+				progress.Hide()
+				var spaceErr *search.SpaceError
+				if errors.As(err, &spaceErr) {
+					err = fmt.Errorf("Not enough space in %s: %s needed, %s available",
+						spaceErr.Dir, formatSize(spaceErr.Required), formatSize(spaceErr.Available))
 				}
+				dialog.ShowError(err, window)
+				return
+			}
 
-				if err := search.HandleFileOperation(file.Path, fileOp); err != nil {
-					search.LogError("Failed to process file %s: %v", file.Path, err)
-					continue
+			var finished int32
+			processor := search.NewFileOperationProcessor(search.ProcessorOptions{
+				Workers:      runtime.NumCPU(),
+				MaxQueueSize: 1000,
+				OnResult: func(search.OperationResult) {
+					progress.SetValue(float64(atomic.AddInt32(&finished, 1)) / float64(total))
+				},
+			})
+			if err := processor.Start(); err != nil {
+				progress.Hide()
+				dialog.ShowError(fmt.Errorf("Failed to start processor: %v", err), window)
+				return
+			}
+
+			for _, file := range filesToProcess {
+				// Blocks while the queue is full
+				processor.Add(file.Path, fileOp, nil)
+			}
+			processor.Stop()
+			summary := processor.Summary()
+
+			// Close the archive before the journal, which records the
+			// files of parts that could not be finished as lost
+			var parts []string
+			var archiveErr error
+			if fileOp.Archiver != nil {
+				parts, archiveErr = fileOp.Archiver.Close()
+				for _, r := range fileOp.Archiver.Lost() {
+					summary.Add(r)
 				}
-				atomic.AddInt32(&processed, 1)
 			}
 
-			if progress != nil {
-				progress.Hide()
+			progress.Hide()
+
+			recorded := !fileOp.Journal.Empty()
+			if err := fileOp.Journal.Close(); err != nil {
+				search.LogError("Failed to close journal: %v", err)
+			}
+			if recorded && fileOp.Operation != search.DeleteFiles {
+				panel.lastJournal = fileOp.Journal.ID()
+				panel.UndoBtn.Enable()
+			}
+
+			done, skipped, failed, _ := summary.Counts()
+			message := fmt.Sprintf("Processed %d, skipped %d, failed %d", done, skipped, failed)
+			if fileOp.Archiver != nil {
+				message += fmt.Sprintf("\nWrote %d archive(s) to %s", len(parts), fileOp.TargetDir)
+				if archiveErr != nil {
+					message += fmt.Sprintf("\n%v", archiveErr)
+				}
 			}
 
 			// Show completion dialog
-			dialog.ShowInformation("Operation Complete", 
-				fmt.Sprintf("Processed %d files", processed), window)
+			showOperationSummary(message, summary.Failures(), window)
 
 			// Clear results if files were moved or deleted
-			if fileOp.Operation == search.MoveFiles || fileOp.Operation == search.DeleteFiles {
+			if fileOp.Operation == search.MoveFiles || fileOp.Operation == search.DeleteFiles ||
+				fileOp.Operation == search.TrashFiles {
 				panel.mu.Lock()
 				*foundFiles = make([]FileListItem, 0)
 				panel.mu.Unlock()
@@ -152,11 +245,192 @@ func CreateFileOperationsPanel(window fyne.Window, foundFiles *[]FileListItem) *
 		}()
 	}
 	
+	panel.UndoBtn = widget.NewButton("Undo Last Operation", func() {
+		dialog.ShowConfirm("Undo Last Operation",
+			"Move files back, restore trashed files and remove copies made by the last operation?",
+			func(ok bool) {
+				if ok {
+					panel.undo(window)
+				}
+			}, window)
+	})
+
 	panel.OperationBtn.Disable() // Disabled by default
+	panel.PreviewBtn.Disable()
+	panel.UndoBtn.Disable()
 	
 	return panel
 }
 
+// fileOperation builds the operation options from the panel widgets
+func (p *FileOperationsPanel) fileOperation() (search.FileOperationOptions, error) {
+	var fileOp search.FileOperationOptions
+	switch p.OpTypeSelect.Selected {
+	case "Copy Files":
+		fileOp.Operation = search.CopyFiles
+	case "Move Files":
+		fileOp.Operation = search.MoveFiles
+	case "Archive Files (zip)":
+		fileOp.Operation = search.ArchiveFiles
+		fileOp.ArchiveFormat = search.ZipFormat
+	case "Archive Files (tar.gz)":
+		fileOp.Operation = search.ArchiveFiles
+		fileOp.ArchiveFormat = search.TarGzFormat
+	case "Move to Trash":
+		fileOp.Operation = search.TrashFiles
+	case "Delete Permanently":
+		fileOp.Operation = search.DeleteFiles
+	default:
+		return fileOp, fmt.Errorf("Please select an operation")
+	}
+
+	if fileOp.Operation != search.DeleteFiles && fileOp.Operation != search.TrashFiles {
+		if p.TargetDir == "" {
+			return fileOp, fmt.Errorf("Please select target directory")
+		}
+	}
+
+	fileOp.TargetDir = p.TargetDir
+	fileOp.Roots = p.SearchRoots
+	switch p.LayoutSelect.Selected {
+	case "Keep Folder Structure":
+		fileOp.PreserveStructure = true
+	case "Path in File Name":
+		fileOp.FlattenPaths = true
+	}
+	fileOp.Verify = p.VerifyCheck.Checked
+	if p.PreserveCheck.Checked {
+		fileOp.PreserveAttributes = search.PreserveAll
+	}
+
+	var limits search.TransferLimits
+	if rate := strings.TrimSpace(p.RateEntry.Text); rate != "" {
+		bytes, err := config.ParseSize(rate)
+		if err != nil {
+			return fileOp, fmt.Errorf("Invalid copy rate: %v", err)
+		}
+		limits.MaxBytesPerSecond = bytes
+	}
+	if p.ParallelSelect.Selected != "Auto" {
+		limits.MaxConcurrentFiles, _ = strconv.Atoi(p.ParallelSelect.Selected)
+	}
+	fileOp.Limiter = search.NewTransferLimiter(limits)
+
+	switch p.ConflictPolicy.Selected {
+	case "Skip":
+		fileOp.ConflictPolicy = search.Skip
+	case "Overwrite":
+		fileOp.ConflictPolicy = search.Overwrite
+	case "Rename":
+		fileOp.ConflictPolicy = search.Rename
+	}
+	return fileOp, nil
+}
+
+// preview shows what the selected operation would do with the found files
+// and lets the user apply it from there
+func (p *FileOperationsPanel) preview(window fyne.Window, foundFiles *[]FileListItem) {
+	fileOp, err := p.fileOperation()
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	fileOp.DryRun = true
+	fileOp.Plan = search.NewOperationPlan()
+
+	p.mu.Lock()
+	files := make([]FileListItem, len(*foundFiles))
+	copy(files, *foundFiles)
+	p.mu.Unlock()
+	if len(files) == 0 {
+		dialog.ShowError(fmt.Errorf("No files found to process"), window)
+		return
+	}
+
+	go func() {
+		for _, file := range files {
+			if err := search.HandleFileOperation(file.Path, fileOp); err != nil {
+				search.LogError("Failed to plan file %s: %v", file.Path, err)
+			}
+		}
+
+		entries := fileOp.Plan.Entries()
+		count, bytes, skipped := fileOp.Plan.Totals()
+		list := widget.NewList(
+			func() int { return len(entries) },
+			func() fyne.CanvasObject { return widget.NewLabel("") },
+			func(i widget.ListItemID, o fyne.CanvasObject) {
+				e := entries[i]
+				text := fmt.Sprintf("%s  %s", e.Action, e.Source)
+				switch {
+				case e.Action == search.PlanSkip:
+					text += " (" + e.Reason + ")"
+				case e.Target != "":
+					text += " -> " + e.Target
+				}
+				o.(*widget.Label).SetText(text)
+			},
+		)
+		summary := widget.NewLabel(fmt.Sprintf("Would process %d files (%s), skip %d",
+			count, formatSize(bytes), skipped))
+		if count == 0 {
+			summary.SetText(fmt.Sprintf("Nothing to do, %d files would be skipped", skipped))
+		}
+
+		content := container.NewBorder(summary, nil, nil, nil, list)
+		d := dialog.NewCustomConfirm("Operation Preview", "Apply", "Close", content, func(apply bool) {
+			if apply {
+				p.OperationBtn.OnTapped()
+			}
+		}, window)
+		d.Resize(fyne.NewSize(700, 500))
+		d.Show()
+	}()
+}
+
+// showOperationSummary shows the totals of a finished operation together with
+// the files that failed
+func showOperationSummary(message string, failures []search.OperationResult, window fyne.Window) {
+	if len(failures) == 0 {
+		dialog.ShowInformation("Operation Complete", message, window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int { return len(failures) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(fmt.Sprintf("%s: %v", failures[i].Path, failures[i].Err))
+		},
+	)
+	content := container.NewBorder(widget.NewLabel(message), nil, nil, nil, list)
+	d := dialog.NewCustom("Operation Complete", "Close", content, window)
+	d.Resize(fyne.NewSize(700, 400))
+	d.Show()
+}
+
+// undo reverses the operation recorded in the last journal
+func (p *FileOperationsPanel) undo(window fyne.Window) {
+	dir, err := search.DefaultJournalDir()
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	undone, errs := search.UndoJournal(dir, p.lastJournal)
+	for _, err := range errs {
+		search.LogError("Undo: %v", err)
+	}
+	message := fmt.Sprintf("Undid %d operations", undone)
+	if len(errs) > 0 {
+		// Keep the button so the failed files can be retried
+		message += fmt.Sprintf(", %d failed:\n%v\nUndo again to retry them.", len(errs), errs[0])
+	} else {
+		p.lastJournal = ""
+		p.UndoBtn.Disable()
+	}
+	dialog.ShowInformation("Undo Complete", message, window)
+}
+
 // GetContent returns the container with all file operations panel widgets
 func (p *FileOperationsPanel) GetContent() *fyne.Container {
 	return container.NewVBox(
@@ -166,17 +440,29 @@ func (p *FileOperationsPanel) GetContent() *fyne.Container {
 		p.TargetDirLabel,
 		p.SelectTargetBtn,
 		widget.NewSeparator(),
+		widget.NewLabel("Target Layout:"),
+		p.LayoutSelect,
+		p.PreserveCheck,
+		p.VerifyCheck,
+		widget.NewSeparator(),
+		widget.NewLabel("Max Copy Rate per Second:"),
+		p.RateEntry,
+		widget.NewLabel("Files at Once:"),
+		p.ParallelSelect,
+		widget.NewSeparator(),
 		widget.NewLabel("On File Conflict:"),
 		p.ConflictPolicy,
 	)
 }
 
-// Enable enables the operation button
+// Enable enables the preview and operation buttons
 func (p *FileOperationsPanel) Enable() {
+	p.PreviewBtn.Enable()
 	p.OperationBtn.Enable()
 }
 
-// Disable disables the operation button
+// Disable disables the preview and operation buttons
 func (p *FileOperationsPanel) Disable() {
+	p.PreviewBtn.Disable()
 	p.OperationBtn.Disable()
 } 
\ No newline at end of file
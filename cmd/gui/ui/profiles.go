@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"filesearch/cmd/gui/utils"
+	"filesearch/internal/config"
+	"filesearch/internal/search"
+)
+
+// ProfilePanel contains widgets for loading and saving named search profiles
+type ProfilePanel struct {
+	ProfileSelect *widget.Select
+	SaveBtn       *widget.Button
+	config        *config.Config
+	configPath    string
+	current       config.Profile
+	searchPanel   *SearchPanel
+}
+
+// CreateProfilePanel creates the profile panel and applies the config defaults
+// to the search panel
+func CreateProfilePanel(window fyne.Window, searchPanel *SearchPanel) *ProfilePanel {
+	panel := &ProfilePanel{
+		searchPanel: searchPanel,
+		config:      &config.Config{},
+	}
+
+	if path, err := config.DefaultPath(); err != nil {
+		search.LogError("Failed to locate config file: %v", err)
+	} else {
+		panel.configPath = path
+		if cfg, err := config.Load(path); err != nil {
+			search.LogError("Failed to load config: %v", err)
+		} else {
+			panel.config = cfg
+		}
+	}
+
+	panel.ProfileSelect = widget.NewSelect(panel.config.ProfileNames(), func(name string) {
+		profile, err := panel.config.Profile(name)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		panel.apply(profile)
+		search.LogInfo("Loaded profile: %s", name)
+	})
+	panel.ProfileSelect.PlaceHolder = "Select profile"
+
+	panel.SaveBtn = widget.NewButton("Save Profile", func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(panel.ProfileSelect.Selected)
+		nameItem := widget.NewFormItem("Name", nameEntry)
+		nameItem.HintText = "Rewrites the config file; comments in it are not kept"
+		dialog.ShowForm("Save Profile", "Save", "Cancel",
+			[]*widget.FormItem{nameItem},
+			func(ok bool) {
+				if !ok || nameEntry.Text == "" {
+					return
+				}
+				if err := panel.save(nameEntry.Text); err != nil {
+					search.LogError("Failed to save profile %s: %v", nameEntry.Text, err)
+					dialog.ShowError(fmt.Errorf("Failed to save profile: %v", err), window)
+				}
+			}, window)
+	})
+
+	panel.apply(panel.config.Defaults)
+
+	return panel
+}
+
+// GetContent returns the container with all profile panel widgets
+func (p *ProfilePanel) GetContent() *fyne.Container {
+	return container.NewVBox(
+		widget.NewLabel("Profile:"),
+		p.ProfileSelect,
+		p.SaveBtn,
+	)
+}
+
+// ApplyTo copies the settings of the loaded profile that have no widgets in
+// the search panel (excludes, size/age filters, workers) into opts
+func (p *ProfilePanel) ApplyTo(opts *search.SearchOptions) error {
+	hidden := p.current
+	hidden.Roots = nil
+	hidden.Patterns = nil
+	hidden.Extensions = nil
+	hidden.IgnoreCase = nil
+	hidden.Archives = nil
+	return hidden.Apply(opts)
+}
+
+// apply fills the search panel widgets from a profile. Fields the profile
+// leaves unset are cleared so nothing carries over from the previous one.
+func (p *ProfilePanel) apply(profile config.Profile) {
+	p.current = profile
+
+	p.searchPanel.SelectedDirs = append([]string(nil), profile.Roots...)
+	p.searchPanel.updateDirsLabel()
+	p.searchPanel.PatternEntry.SetText(strings.Join(profile.Patterns, ", "))
+	p.searchPanel.ExtensionEntry.SetText(strings.Join(profile.Extensions, ", "))
+	p.searchPanel.IgnoreCaseCheck.SetChecked(profile.IgnoreCase != nil && *profile.IgnoreCase)
+	p.searchPanel.ArchivesCheck.SetChecked(profile.Archives != nil && *profile.Archives)
+}
+
+// save stores the current search panel state as a named profile
+func (p *ProfilePanel) save(name string) error {
+	if p.configPath == "" {
+		return fmt.Errorf("config file location is unknown")
+	}
+
+	// Keep settings that the panel does not edit
+	profile := p.config.Profiles[name]
+	profile.Roots = append([]string(nil), p.searchPanel.SelectedDirs...)
+	profile.Patterns = utils.SplitCommaList(p.searchPanel.PatternEntry.Text)
+	profile.Extensions = utils.SplitCommaList(p.searchPanel.ExtensionEntry.Text)
+	profile.IgnoreCase = config.Bool(p.searchPanel.IgnoreCaseCheck.Checked)
+	profile.Archives = config.Bool(p.searchPanel.ArchivesCheck.Checked)
+
+	p.config.SetProfile(name, profile)
+	if err := p.config.Save(p.configPath); err != nil {
+		return err
+	}
+
+	p.ProfileSelect.Options = p.config.ProfileNames()
+	p.ProfileSelect.SetSelected(name)
+	search.LogInfo("Saved profile %s to %s", name, p.configPath)
+	return nil
+}
@@ -14,6 +14,7 @@ type SearchPanel struct {
 	PatternEntry    *widget.Entry
 	ExtensionEntry  *widget.Entry
 	IgnoreCaseCheck *widget.Check
+	ArchivesCheck   *widget.Check
 	DirsLabel       *widget.Label
 	SelectedDirs    []string
 	addDirBtn       *widget.Button
@@ -28,6 +29,7 @@ func CreateSearchPanel(window fyne.Window) *SearchPanel {
 		PatternEntry: widget.NewEntry(),
 		ExtensionEntry: widget.NewEntry(),
 		IgnoreCaseCheck: widget.NewCheck("Ignore case", nil),
+		ArchivesCheck: widget.NewCheck("Search inside archives", nil),
 		DirsLabel: widget.NewLabel(""),
 		SelectedDirs: make([]string, 0),
 	}
@@ -82,6 +84,7 @@ func (p *SearchPanel) GetContent() *fyne.Container {
 		p.PatternEntry,
 		p.ExtensionEntry,
 		p.IgnoreCaseCheck,
+		p.ArchivesCheck,
 		p.searchBtn,
 		p.stopBtn,
 		p.addDirBtn,
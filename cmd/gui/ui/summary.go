@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"filesearch/internal/search"
+)
+
+// summaryRows limits the number of entries shown per breakdown
+const summaryRows = 5
+
+// SummaryPanel shows totals of the last completed search
+type SummaryPanel struct {
+	Label *widget.Label
+}
+
+// CreateSummaryPanel creates an empty results summary panel
+func CreateSummaryPanel() *SummaryPanel {
+	panel := &SummaryPanel{
+		Label: widget.NewLabel("No search results yet"),
+	}
+	panel.Label.Wrapping = fyne.TextWrapWord
+	return panel
+}
+
+// GetContent returns the panel widget
+func (p *SummaryPanel) GetContent() fyne.CanvasObject {
+	return p.Label
+}
+
+// SetSummary displays the summary of a completed search
+func (p *SummaryPanel) SetSummary(s search.SearchSummary) {
+	if s.Files == 0 {
+		p.Label.SetText("No files found")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d files, %s\n", s.Files, formatSize(s.TotalSize))
+
+	if len(s.Roots) > 1 {
+		b.WriteString("\nBy root:\n")
+		writeDirStats(&b, s.Roots)
+	}
+	b.WriteString("\nTop directories:\n")
+	writeDirStats(&b, s.TopDirs)
+
+	b.WriteString("\nTop extensions:\n")
+	for i, e := range s.Extensions {
+		if i == summaryRows {
+			break
+		}
+		ext := e.Ext
+		if ext == "" {
+			ext = "(none)"
+		}
+		fmt.Fprintf(&b, "  %s: %d files, %s\n", ext, e.Files, formatSize(e.TotalSize))
+	}
+
+	b.WriteString("\nLargest files:\n")
+	for i, r := range s.Largest {
+		if i == summaryRows {
+			break
+		}
+		fmt.Fprintf(&b, "  %s (%s)\n", filepath.Base(r.Path), formatSize(r.Size))
+	}
+
+	fmt.Fprintf(&b, "\nOldest: %s (%s)\n", filepath.Base(s.Oldest.Path), s.Oldest.ModTime.Format("2006-01-02"))
+	fmt.Fprintf(&b, "Newest: %s (%s)", filepath.Base(s.Newest.Path), s.Newest.ModTime.Format("2006-01-02"))
+
+	p.Label.SetText(b.String())
+}
+
+// writeDirStats writes the largest directories by total size
+func writeDirStats(b *strings.Builder, stats map[string]*search.DirStats) {
+	dirs := make([]string, 0, len(stats))
+	for dir := range stats {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return stats[dirs[i]].TotalSize > stats[dirs[j]].TotalSize
+	})
+
+	for i, dir := range dirs {
+		if i == summaryRows {
+			break
+		}
+		fmt.Fprintf(b, "  %s: %d files, %s\n", dir, stats[dir].FileCount, formatSize(stats[dir].TotalSize))
+	}
+}
+
+// formatSize formats file size in human-readable form
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1024*1024*1024))
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
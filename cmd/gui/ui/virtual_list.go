@@ -241,19 +241,7 @@ func (v *VirtualFileList) preCacheRange(start, end int) {
 
 // formatFileItem formats a file item for display
 func (v *VirtualFileList) formatFileItem(item FileListItem) string {
-	var sizeStr string
-	switch {
-	case item.Size >= 1024*1024*1024:
-		sizeStr = fmt.Sprintf("%.1f GB", float64(item.Size)/(1024*1024*1024))
-	case item.Size >= 1024*1024:
-		sizeStr = fmt.Sprintf("%.1f MB", float64(item.Size)/(1024*1024))
-	case item.Size >= 1024:
-		sizeStr = fmt.Sprintf("%.1f KB", float64(item.Size)/1024)
-	default:
-		sizeStr = fmt.Sprintf("%d B", item.Size)
-	}
-	
-	return fmt.Sprintf("%s (%s)", item.Path, sizeStr)
+	return fmt.Sprintf("%s (%s)", item.Path, formatSize(item.Size))
 }
 
 // refreshVisible refreshes only the visible portion efficiently
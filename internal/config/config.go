@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+
+	"filesearch/internal/search"
+)
+
+// Output formats supported by the CLI
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Profile bundles a reusable set of search parameters
+type Profile struct {
+	Roots         []string `toml:"roots,omitempty"`
+	Patterns      []string `toml:"patterns,omitempty"`
+	Extensions    []string `toml:"extensions,omitempty"`
+	ExcludeDirs   []string `toml:"exclude_dirs,omitempty"`
+	IgnoreCase    *bool    `toml:"ignore_case,omitempty"`
+	ExcludeHidden *bool    `toml:"exclude_hidden,omitempty"`
+	Archives      *bool    `toml:"archives,omitempty"` // search inside zip/tar archives
+	MinSize       string   `toml:"min_size,omitempty"` // e.g. "1KB", "1.5MB"
+	MaxSize       string   `toml:"max_size,omitempty"`
+	MinAge        string   `toml:"min_age,omitempty"` // e.g. "1h", "2d", "1w"
+	MaxAge        string   `toml:"max_age,omitempty"`
+	Owner         string   `toml:"owner,omitempty"` // user name or uid
+	Group         string   `toml:"group,omitempty"` // group name or gid
+	Perm          string   `toml:"perm,omitempty"`  // e.g. "644" or "-4000", see ParsePerm
+	Workers       int      `toml:"workers,omitempty"`
+	Output        string   `toml:"output,omitempty"` // "text" or "json"
+}
+
+// Config is the on-disk configuration with defaults and named profiles
+type Config struct {
+	Defaults Profile            `toml:"defaults"`
+	Profiles map[string]Profile `toml:"profiles"`
+}
+
+// DefaultPath returns the default config file location
+// ($XDG_CONFIG_HOME/koe/config.toml on Linux)
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %v", err)
+	}
+	return filepath.Join(dir, "koe", "config.toml"), nil
+}
+
+// Load reads the config file at path. A missing file yields an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{Profiles: make(map[string]Profile)}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config to path atomically, creating parent directories.
+// The whole file is re-encoded, so comments in it are not kept.
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create config file: %v", err)
+	}
+
+	if err := toml.NewEncoder(f).Encode(c); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close config file: %v", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// ProfileNames returns the names of all profiles in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the named profile layered over the defaults.
+// An empty name returns the defaults alone.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		return c.Defaults, nil
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile: %s", name)
+	}
+
+	return c.Defaults.merge(p), nil
+}
+
+// SetProfile stores a profile under the given name
+func (c *Config) SetProfile(name string, p Profile) {
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	c.Profiles[name] = p
+}
+
+// merge returns base with every field that is set in override replaced
+func (base Profile) merge(override Profile) Profile {
+	result := base
+	if len(override.Roots) > 0 {
+		result.Roots = override.Roots
+	}
+	if len(override.Patterns) > 0 {
+		result.Patterns = override.Patterns
+	}
+	if len(override.Extensions) > 0 {
+		result.Extensions = override.Extensions
+	}
+	if len(override.ExcludeDirs) > 0 {
+		result.ExcludeDirs = override.ExcludeDirs
+	}
+	if override.IgnoreCase != nil {
+		result.IgnoreCase = override.IgnoreCase
+	}
+	if override.ExcludeHidden != nil {
+		result.ExcludeHidden = override.ExcludeHidden
+	}
+	if override.Archives != nil {
+		result.Archives = override.Archives
+	}
+	if override.MinSize != "" {
+		result.MinSize = override.MinSize
+	}
+	if override.MaxSize != "" {
+		result.MaxSize = override.MaxSize
+	}
+	if override.MinAge != "" {
+		result.MinAge = override.MinAge
+	}
+	if override.MaxAge != "" {
+		result.MaxAge = override.MaxAge
+	}
+	if override.Owner != "" {
+		result.Owner = override.Owner
+	}
+	if override.Group != "" {
+		result.Group = override.Group
+	}
+	if override.Perm != "" {
+		result.Perm = override.Perm
+	}
+	if override.Workers > 0 {
+		result.Workers = override.Workers
+	}
+	if override.Output != "" {
+		result.Output = override.Output
+	}
+	return result
+}
+
+// Apply copies the profile settings into the search options
+func (p Profile) Apply(opts *search.SearchOptions) error {
+	if len(p.Roots) > 0 {
+		opts.RootDirs = p.Roots
+	}
+	if len(p.Patterns) > 0 {
+		opts.Patterns = p.Patterns
+	}
+	if len(p.Extensions) > 0 {
+		opts.Extensions = p.Extensions
+	}
+	if len(p.ExcludeDirs) > 0 {
+		opts.ExcludeDirs = append(opts.ExcludeDirs, p.ExcludeDirs...)
+	}
+	if p.IgnoreCase != nil {
+		opts.IgnoreCase = *p.IgnoreCase
+	}
+	if p.ExcludeHidden != nil {
+		opts.ExcludeHidden = *p.ExcludeHidden
+	}
+	if p.Archives != nil {
+		opts.SearchArchives = *p.Archives
+	}
+	if p.Workers > 0 {
+		opts.MaxWorkers = p.Workers
+	}
+
+	if p.MinSize != "" {
+		size, err := ParseSize(p.MinSize)
+		if err != nil {
+			return fmt.Errorf("invalid min_size %q: %v", p.MinSize, err)
+		}
+		opts.MinSize = size
+	}
+	if p.MaxSize != "" {
+		size, err := ParseSize(p.MaxSize)
+		if err != nil {
+			return fmt.Errorf("invalid max_size %q: %v", p.MaxSize, err)
+		}
+		opts.MaxSize = size
+	}
+	if p.MinAge != "" {
+		age, err := ParseAge(p.MinAge)
+		if err != nil {
+			return fmt.Errorf("invalid min_age %q: %v", p.MinAge, err)
+		}
+		opts.MinAge = age
+	}
+	if p.MaxAge != "" {
+		age, err := ParseAge(p.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max_age %q: %v", p.MaxAge, err)
+		}
+		opts.MaxAge = age
+	}
+	if p.Owner != "" {
+		if _, err := search.LookupOwner(p.Owner); err != nil {
+			return err
+		}
+		opts.Owner = p.Owner
+	}
+	if p.Group != "" {
+		if _, err := search.LookupGroup(p.Group); err != nil {
+			return err
+		}
+		opts.Group = p.Group
+	}
+	if p.Perm != "" {
+		mask, perm, err := ParsePerm(p.Perm)
+		if err != nil {
+			return fmt.Errorf("invalid perm %q: %v", p.Perm, err)
+		}
+		opts.PermMask, opts.Perm = mask, perm
+	}
+
+	return nil
+}
+
+// Bool returns a pointer to b, for filling optional profile fields
+func Bool(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseSize parses size string (e.g., "1KB", "1.5MB", "2GB") into bytes
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	
+	multiplier := int64(1)
+	if strings.HasSuffix(s, "KB") {
+		multiplier = 1024
+		s = s[:len(s)-2]
+	} else if strings.HasSuffix(s, "MB") {
+		multiplier = 1024 * 1024
+		s = s[:len(s)-2]
+	} else if strings.HasSuffix(s, "GB") {
+		multiplier = 1024 * 1024 * 1024
+		s = s[:len(s)-2]
+	} else if strings.HasSuffix(s, "B") {
+		s = s[:len(s)-1]
+	}
+	
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	
+	return int64(value * float64(multiplier)), nil
+}
+
+// ParseAge parses age string (e.g., "1h", "2d", "1w", "1m") into duration
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	
+	multiplier := time.Hour
+	if strings.HasSuffix(s, "h") {
+		s = s[:len(s)-1]
+	} else if strings.HasSuffix(s, "d") {
+		multiplier = time.Hour * 24
+		s = s[:len(s)-1]
+	} else if strings.HasSuffix(s, "w") {
+		multiplier = time.Hour * 24 * 7
+		s = s[:len(s)-1]
+	} else if strings.HasSuffix(s, "m") {
+		multiplier = time.Hour * 24 * 30
+		s = s[:len(s)-1]
+	}
+	
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	
+	return time.Duration(float64(multiplier) * value), nil
+}
+
+// ParsePerm parses a find-style octal mode into a mask and required value.
+// "644" matches exactly that mode (including special bits), "-4000" matches
+// files with all the given bits set, e.g. "-0002" for world-writable files.
+func ParsePerm(s string) (mask, perm os.FileMode, err error) {
+	s = strings.TrimSpace(s)
+	all := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	value, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || value > 07777 {
+		return 0, 0, fmt.Errorf("expected octal mode like 644 or -4000")
+	}
+
+	perm = os.FileMode(value & 0777)
+	if value&04000 != 0 {
+		perm |= os.ModeSetuid
+	}
+	if value&02000 != 0 {
+		perm |= os.ModeSetgid
+	}
+	if value&01000 != 0 {
+		perm |= os.ModeSticky
+	}
+
+	if all {
+		return perm, perm, nil
+	}
+	return os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky, perm, nil
+}
+
+// dateLayouts are the accepted ParseDate formats, most specific first
+var dateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseDate parses an absolute date such as "2026-09-01", "2026-09-01 14:30"
+// or an RFC 3339 timestamp. Dates without a zone are in local time.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected a date like 2026-09-01 or 2026-09-01 14:30")
+}
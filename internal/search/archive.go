@@ -0,0 +1,204 @@
+package search
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cespare/xxhash"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ArchiveSeparator separates the archive path from the entry name in
+// virtual paths such as "backup.zip!/docs/report.pdf"
+const ArchiveSeparator = "!/"
+
+// archiveEntryReason is why file operations skip virtual archive entries
+const archiveEntryReason = "entry inside an archive"
+
+// archiveKind identifies a supported archive format
+type archiveKind int
+
+const (
+	notArchive archiveKind = iota
+	zipArchive
+	tarArchive
+	tarGzArchive
+	tarZstArchive
+)
+
+// archiveKindOf detects the archive format from the file name
+func archiveKindOf(name string) archiveKind {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".jar"):
+		return zipArchive
+	case strings.HasSuffix(name, ".tar"):
+		return tarArchive
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return tarGzArchive
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return tarZstArchive
+	default:
+		return notArchive
+	}
+}
+
+// SplitArchivePath splits a virtual path into the archive file and the entry
+// name. ok is false for ordinary paths.
+func SplitArchivePath(p string) (archive, entry string, ok bool) {
+	i := strings.Index(p, ArchiveSeparator)
+	if i < 0 {
+		return p, "", false
+	}
+	return p[:i], p[i+len(ArchiveSeparator):], true
+}
+
+// isArchiveEntry reports whether p is the virtual path of an archive entry
+// rather than a file whose directory name happens to end in "!"
+func isArchiveEntry(p string) bool {
+	archive, _, ok := SplitArchivePath(p)
+	if !ok || archiveKindOf(archive) == notArchive {
+		return false
+	}
+	_, err := os.Lstat(p)
+	return err != nil
+}
+
+// errStopped ends an archive scan when the search is stopped
+var errStopped = errors.New("search stopped")
+
+// scanArchive matches the entries of an archive and sends them as results
+// with virtual paths. Size and mtime come from the archive headers.
+func scanArchive(archivePath string, kind archiveKind, patterns compiledPatterns, opts SearchOptions, processor *resultProcessor) error {
+	visit := func(name string, info fs.FileInfo, uid, gid int) error {
+		select {
+		case <-opts.StopChan:
+			return errStopped
+		default:
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+		opts.Progress.examinedFiles(1)
+
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if !matchesPatterns(name, patterns, opts.IgnoreCase) ||
+			!matchesArchiveEntry(info, opts) {
+			return nil
+		}
+
+		virtual := archivePath + ArchiveSeparator + name
+		processor.add(SearchResult{
+			Path:    virtual,
+			Size:    info.Size(),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+			Hash:    archiveEntryHash(virtual, info),
+			UID:     uid,
+			GID:     gid,
+		})
+		return nil
+	}
+
+	var err error
+	if kind == zipArchive {
+		err = scanZip(archivePath, visit)
+	} else {
+		err = scanTar(archivePath, kind, visit)
+	}
+	if err == errStopped {
+		return nil
+	}
+	return err
+}
+
+// matchesArchiveEntry checks the filters that apply to an archive entry.
+// Headers carry no ctime or atime, and the stat-based owner lookup does not
+// work on them, so owner, group, ctime and atime filters are skipped.
+func matchesArchiveEntry(info fs.FileInfo, opts SearchOptions) bool {
+	if !matchesSizeAndAge(info.Size(), info.ModTime(), opts) {
+		return false
+	}
+	for _, f := range opts.TimeFilters {
+		if f.Field == ModifiedTime && !f.contains(info.ModTime()) {
+			return false
+		}
+	}
+	return opts.PermMask == 0 || info.Mode()&opts.PermMask == opts.Perm
+}
+
+// scanZip calls visit for every entry of a zip or jar file
+func scanZip(archivePath string, visit func(string, fs.FileInfo, int, int) error) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if err := visit(f.Name, f.FileInfo(), -1, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanTar calls visit for every entry of a plain or compressed tar file
+func scanTar(archivePath string, kind archiveKind, visit func(string, fs.FileInfo, int, int) error) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch kind {
+	case tarGzArchive:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case tarZstArchive:
+		zr, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := visit(hdr.Name, hdr.FileInfo(), hdr.Uid, hdr.Gid); err != nil {
+			return err
+		}
+	}
+}
+
+// archiveEntryHash builds a quick hash for an archive entry from its virtual
+// path and header metadata, since entries are not read
+func archiveEntryHash(virtual string, info fs.FileInfo) uint64 {
+	h := xxhash.New()
+	h.Write([]byte(virtual))
+	binary.Write(h, binary.LittleEndian, info.Size())
+	binary.Write(h, binary.LittleEndian, info.ModTime().UnixNano())
+	return h.Sum64()
+}
@@ -0,0 +1,527 @@
+package search
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash"
+)
+
+// ArchiveFormat selects the container written by ArchiveFiles
+type ArchiveFormat int
+
+const (
+	ZipFormat ArchiveFormat = iota
+	TarGzFormat
+)
+
+// Extension returns the file name extension of the format
+func (f ArchiveFormat) Extension() string {
+	if f == TarGzFormat {
+		return ".tar.gz"
+	}
+	return ".zip"
+}
+
+// manifestName is the name of the manifest entry written into each part
+const manifestName = "MANIFEST.txt"
+
+// spoolMemoryLimit is the largest file that is read into memory before it is
+// written to the archive; larger files are spooled to a temporary file
+const spoolMemoryLimit = 16 * 1024 * 1024
+
+// Archiver streams files into a zip or tar.gz archive in the target directory,
+// starting a new part whenever the current one reaches SplitSize. Parts are
+// compressed in flushed blocks when split, so a part ends up larger than
+// SplitSize by at most one file and the closing headers.
+// It is safe for concurrent use; files are written one at a time.
+type Archiver struct {
+	opts  FileOperationOptions
+	roots []string
+	base  string // Path of the archive without extension
+
+	mu        sync.Mutex
+	part      *archivePart
+	parts     []string
+	discarded []string          // Parts removed after a write error
+	lost      []OperationResult // Files reported done whose part was lost
+	names     map[string]bool   // Entry names used in the current part
+	closed    bool
+}
+
+// archivePart is one archive file being written
+type archivePart struct {
+	path     string
+	tmpPath  string
+	file     *os.File
+	counter  *countingWriter
+	zip      *zip.Writer
+	deflate  *flate.Writer // Compressor of the current zip entry
+	gzip     *gzip.Writer
+	tar      *tar.Writer
+	manifest strings.Builder
+	entries  int
+	files    []OperationResult // Results of the files written to the part
+}
+
+// countingWriter counts the bytes written to the archive file
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewArchiver prepares an archive in opts.TargetDir. Entry names are made
+// relative to the first of roots that contains each file. The archive file
+// name is resolved against existing files with opts.ConflictPolicy.
+func NewArchiver(opts FileOperationOptions, roots []string) (*Archiver, error) {
+	if opts.TargetDir == "" {
+		return nil, fmt.Errorf("target directory is required")
+	}
+	if opts.CompressionLevel < 0 || opts.CompressionLevel > 9 {
+		return nil, fmt.Errorf("compression level must be between 1 and 9")
+	}
+	if err := os.MkdirAll(opts.TargetDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create target directory: %v", err)
+	}
+
+	name := opts.ArchiveName
+	if name == "" {
+		name = "koe-" + time.Now().Format("20060102-150405")
+	}
+	name = strings.TrimSuffix(name, opts.ArchiveFormat.Extension())
+
+	// The first part decides the name; later parts follow its numbering
+	ext := opts.ArchiveFormat.Extension()
+	firstPart := func(base string) string {
+		if opts.SplitSize > 0 {
+			return base + ".part001" + ext
+		}
+		return base + ext
+	}
+	base := filepath.Join(opts.TargetDir, name)
+	if _, err := os.Stat(firstPart(base)); err == nil {
+		switch opts.ConflictPolicy {
+		case Skip:
+			return nil, fmt.Errorf("archive already exists: %s", firstPart(base))
+		case Rename:
+			// resolveConflict would split ".tar.gz", so number the name here
+			for i := 1; ; i++ {
+				candidate := fmt.Sprintf("%s_%d", base, i)
+				_, err := os.Stat(firstPart(candidate))
+				if os.IsNotExist(err) {
+					base = candidate
+					break
+				}
+				if err != nil {
+					return nil, fmt.Errorf("failed to check archive name: %v", err)
+				}
+			}
+		}
+	}
+
+	return &Archiver{
+		opts:  opts,
+		roots: roots,
+		base:  base,
+	}, nil
+}
+
+// Add writes a regular file into the archive
+func (a *Archiver) Add(path string) error {
+	_, err := a.add(path, nil)
+	return err
+}
+
+// add writes a regular file into the archive and returns its virtual path,
+// such as backup.zip!/docs/report.pdf. record, which may be nil, journals
+// the entry; it runs under the archiver's lock so that the entry is recorded
+// before the part can be discarded.
+func (a *Archiver) add(path string, record func(target string) error) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get source file info: %v", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file: %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open source: %v", err)
+	}
+	defer f.Close()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return "", fmt.Errorf("archive is closed")
+	}
+	if a.part != nil && a.opts.SplitSize > 0 && a.part.entries > 0 && a.part.counter.n >= a.opts.SplitSize {
+		if err := a.finishPart(); err != nil {
+			return "", err
+		}
+	}
+	if a.part == nil {
+		if err := a.startPart(); err != nil {
+			return "", err
+		}
+	}
+
+	// Read the whole file first: once the header is written, a read error
+	// or a file that shrank would leave a broken entry in the archive
+	content, hash, cleanup, err := a.spool(f, info.Size())
+	if err != nil {
+		return "", fmt.Errorf("failed to archive %s: %v", path, err)
+	}
+	defer cleanup()
+
+	name := a.uniqueName(a.entryName(path))
+	err = a.part.writeEntry(name, info, content)
+	if err == nil && a.opts.SplitSize > 0 {
+		err = a.part.flush()
+	}
+	if err != nil {
+		// The part now ends in a partial entry and cannot be used
+		partPath := a.part.path
+		a.discardPart(err)
+		return "", fmt.Errorf("failed to archive %s, discarded %s: %v", path, partPath, err)
+	}
+
+	a.part.entries++
+	fmt.Fprintf(&a.part.manifest, "%016x  %12d  %s\n", hash, info.Size(), name)
+	target := a.part.path + ArchiveSeparator + name
+	if record != nil {
+		if err := record(target); err != nil {
+			return "", err
+		}
+	}
+	a.part.files = append(a.part.files, OperationResult{
+		Path:      path,
+		Operation: ArchiveFiles,
+		Status:    OpDone,
+		Target:    target,
+		Bytes:     info.Size(),
+	})
+	return target, nil
+}
+
+// spool reads size bytes of f into memory or a temporary file in the target
+// directory and returns a reader for them with their hash. cleanup removes
+// the temporary file.
+func (a *Archiver) spool(f *os.File, size int64) (io.Reader, uint64, func(), error) {
+	hash := xxhash.New()
+	src := io.TeeReader(f, hash)
+	sizeError := func(n int64) error {
+		return fmt.Errorf("size mismatch: expected %d, got %d", size, n)
+	}
+
+	if size <= spoolMemoryLimit {
+		buf := make([]byte, size)
+		n, err := io.ReadFull(src, buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, 0, nil, sizeError(int64(n))
+		}
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return bytes.NewReader(buf), hash.Sum64(), func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp(a.opts.TargetDir, ".koe-spool-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to create spool file: %v", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	n, err := io.Copy(tmp, io.LimitReader(src, size))
+	if err == nil && n != size {
+		err = sizeError(n)
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return tmp, hash.Sum64(), cleanup, nil
+}
+
+// writeEntry writes the header and the complete content of a file
+func (p *archivePart) writeEntry(name string, info os.FileInfo, content io.Reader) error {
+	var w io.Writer
+	if p.tar != nil {
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return fmt.Errorf("failed to create header: %v", err)
+		}
+		hdr.Name = name
+		if err := p.tar.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write header: %v", err)
+		}
+		w = p.tar
+	} else {
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return fmt.Errorf("failed to create header: %v", err)
+		}
+		hdr.Name = name
+		hdr.Method = zip.Deflate
+		if w, err = p.zip.CreateHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write header: %v", err)
+		}
+	}
+
+	if written, err := io.Copy(w, content); err != nil {
+		return err
+	} else if written != info.Size() {
+		return fmt.Errorf("size mismatch: expected %d, got %d", info.Size(), written)
+	}
+	return nil
+}
+
+// flush pushes the compressed data written so far to the archive file, so
+// that its size can be checked against SplitSize
+func (p *archivePart) flush() error {
+	if p.tar != nil {
+		if err := p.tar.Flush(); err != nil {
+			return err
+		}
+		return p.gzip.Flush()
+	}
+	if p.deflate != nil {
+		if err := p.deflate.Flush(); err != nil {
+			return err
+		}
+	}
+	return p.zip.Flush()
+}
+
+// discardPart removes the current part after a failed write. The files
+// already written to it are lost, see loseFiles; the next file starts the
+// part again.
+func (a *Archiver) discardPart(cause error) {
+	part := a.part
+	a.part = nil
+	part.file.Close()
+	os.Remove(part.tmpPath)
+	a.discarded = append(a.discarded, part.path)
+	a.loseFiles(part, fmt.Errorf("archive %s was discarded after a write error: %v", part.path, cause))
+	logError("Discarded archive %s with %d files after a write error", part.path, part.entries)
+}
+
+// loseFiles turns the results of the files of a part that was not kept into
+// failures for Lost, and journals the entries as lost
+func (a *Archiver) loseFiles(part *archivePart, cause error) {
+	for _, r := range part.files {
+		if a.opts.Journal != nil {
+			lost := JournalEntry{Time: time.Now(), Operation: journalLost, Source: r.Path, Target: r.Target, Size: r.Bytes}
+			if err := a.opts.Journal.record(lost); err != nil {
+				logError("Failed to journal lost archive entry %s: %v", r.Target, err)
+			}
+		}
+		r.Status = OpFailed
+		r.Target = ""
+		r.Err = cause
+		r.Revised = true
+		a.lost = append(a.lost, r)
+	}
+	part.files = nil
+}
+
+// Lost returns a failed result for every file that was reported done but
+// then lost with its part, because writing to the part or finishing it
+// failed. Call it after Close and add the results to the summary of the run.
+func (a *Archiver) Lost() []OperationResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]OperationResult(nil), a.lost...)
+}
+
+// Close writes the manifest, finishes the last part and returns the paths of
+// all parts that were written. It fails when a part had to be discarded.
+func (a *Archiver) Close() ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return a.parts, nil
+	}
+	a.closed = true
+
+	if a.part != nil {
+		if err := a.finishPart(); err != nil {
+			return a.parts, err
+		}
+	}
+	if len(a.discarded) > 0 {
+		return a.parts, fmt.Errorf("archives discarded after write errors: %s", strings.Join(a.discarded, ", "))
+	}
+	return a.parts, nil
+}
+
+// entryName returns the slash-separated name of path relative to its root
+func (a *Archiver) entryName(path string) string {
+	rel, ok := relativePath(path, a.roots)
+	if !ok {
+		return filepath.Base(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// uniqueName adds a numeric suffix to names already used in the current part
+func (a *Archiver) uniqueName(name string) string {
+	if !a.names[name] && name != manifestName {
+		a.names[name] = true
+		return name
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", stem, i, ext)
+		if !a.names[candidate] {
+			a.names[candidate] = true
+			return candidate
+		}
+	}
+}
+
+// startPart creates the next archive file
+func (a *Archiver) startPart() error {
+	path := a.base + a.opts.ArchiveFormat.Extension()
+	if a.opts.SplitSize > 0 {
+		path = fmt.Sprintf("%s.part%03d%s", a.base, len(a.parts)+1, a.opts.ArchiveFormat.Extension())
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %v", err)
+	}
+
+	level := a.opts.CompressionLevel
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	part := &archivePart{path: path, tmpPath: tmpPath, file: f, counter: &countingWriter{w: f}}
+	switch a.opts.ArchiveFormat {
+	case TarGzFormat:
+		gz, err := gzip.NewWriterLevel(part.counter, level)
+		if err != nil {
+			f.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to create archive: %v", err)
+		}
+		part.gzip = gz
+		part.tar = tar.NewWriter(gz)
+	default:
+		part.zip = zip.NewWriter(part.counter)
+		part.zip.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			fw, err := flate.NewWriter(w, level)
+			part.deflate = fw
+			return fw, err
+		})
+	}
+
+	a.part = part
+	a.names = make(map[string]bool)
+	return nil
+}
+
+// finishPart writes the manifest and moves the current part into place
+func (a *Archiver) finishPart() error {
+	part := a.part
+	a.part = nil
+
+	err := part.writeManifest(a.opts)
+	if err == nil {
+		err = part.close()
+	}
+	if cerr := part.file.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(part.tmpPath)
+		err = fmt.Errorf("failed to finish archive %s: %v", part.path, err)
+		a.loseFiles(part, err)
+		return err
+	}
+
+	if err := os.Rename(part.tmpPath, part.path); err != nil {
+		os.Remove(part.tmpPath)
+		err = fmt.Errorf("rename failed: %v", err)
+		a.loseFiles(part, err)
+		return err
+	}
+	a.parts = append(a.parts, part.path)
+	logInfo("Wrote archive %s with %d files", part.path, part.entries)
+	return nil
+}
+
+// writeManifest adds the manifest entry when enabled
+func (p *archivePart) writeManifest(opts FileOperationOptions) error {
+	if !opts.Manifest {
+		return nil
+	}
+	content := []byte(p.manifest.String())
+
+	if p.tar != nil {
+		hdr := &tar.Header{
+			Name:    manifestName,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := p.tar.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := p.tar.Write(content)
+		return err
+	}
+
+	w, err := p.zip.CreateHeader(&zip.FileHeader{
+		Name:     manifestName,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// close flushes the archive writers
+func (p *archivePart) close() error {
+	if p.tar != nil {
+		if err := p.tar.Close(); err != nil {
+			return err
+		}
+		if err := p.gzip.Close(); err != nil {
+			return err
+		}
+	} else if err := p.zip.Close(); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
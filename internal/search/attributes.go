@@ -0,0 +1,93 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// AttributeFlags selects which source file attributes CopyFiles keeps
+type AttributeFlags int
+
+const (
+	PreserveTimes  AttributeFlags = 1 << iota // Modification and access time
+	PreserveMode                              // Permission bits including setuid, setgid and sticky
+	PreserveOwner                             // Owner and group; silently skipped without the privilege
+	PreserveXattrs                            // Extended attributes, which include POSIX ACLs on Linux
+	PreserveAll    = PreserveTimes | PreserveMode | PreserveOwner | PreserveXattrs
+)
+
+// fileAttributes holds the attributes of a source file, read before copying
+// so that reading the content does not change the recorded access time
+type fileAttributes struct {
+	flags      AttributeFlags
+	mode       os.FileMode
+	modTime    time.Time
+	accessTime time.Time
+	uid, gid   int
+	hasOwner   bool
+	xattrs     map[string][]byte
+}
+
+// readAttributes records the attributes of src selected by flags
+func readAttributes(src string, info os.FileInfo, flags AttributeFlags) (*fileAttributes, error) {
+	attrs := &fileAttributes{
+		flags:   flags,
+		mode:    info.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky),
+		modTime: info.ModTime(),
+	}
+	if flags&PreserveTimes != 0 {
+		// Fall back to mtime where atime is not available
+		attrs.accessTime = info.ModTime()
+		if atime, ok := fileTime(info, AccessedTime); ok {
+			attrs.accessTime = atime
+		}
+	}
+	if flags&PreserveOwner != 0 {
+		attrs.uid, attrs.gid, attrs.hasOwner = fileOwner(info)
+	}
+	if flags&PreserveXattrs != 0 {
+		xattrs, err := readXattrs(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read extended attributes: %v", err)
+		}
+		attrs.xattrs = xattrs
+	}
+	return attrs, nil
+}
+
+// apply sets the recorded attributes on dst. Ownership goes first because
+// changing it clears the setuid and setgid bits, and times go last because
+// the other changes update ctime only. A nil receiver sets nothing.
+func (a *fileAttributes) apply(dst string) error {
+	if a == nil {
+		return nil
+	}
+	if a.flags&PreserveOwner != 0 && a.hasOwner {
+		if err := os.Chown(dst, a.uid, a.gid); err != nil {
+			// Like cp -p, keep the copy when we may not give it away
+			if !errors.Is(err, fs.ErrPermission) {
+				return fmt.Errorf("failed to preserve owner: %v", err)
+			}
+			logDebug("Cannot preserve owner of %s: %v", dst, err)
+		}
+	}
+	if a.flags&PreserveXattrs != 0 {
+		if err := writeXattrs(dst, a.xattrs); err != nil {
+			return fmt.Errorf("failed to preserve extended attributes: %v", err)
+		}
+	}
+	if a.flags&PreserveMode != 0 {
+		if err := os.Chmod(dst, a.mode); err != nil {
+			return fmt.Errorf("failed to preserve mode: %v", err)
+		}
+	}
+	if a.flags&PreserveTimes != 0 {
+		if err := os.Chtimes(dst, a.accessTime, a.modTime); err != nil {
+			return fmt.Errorf("failed to preserve times: %v", err)
+		}
+	}
+	return nil
+}
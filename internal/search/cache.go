@@ -3,9 +3,6 @@ package search
 import (
 	"sync"
 	"time"
-	"os"
-	"strings"
-	"path/filepath"
 )
 
 // Cache stores directory contents for faster subsequent searches
@@ -51,26 +48,3 @@ func (c *Cache) set(dir string, entry *DirEntry) {
 	c.entries[dir] = entry
 	c.lastUpdate = time.Now()
 }
-
-// updateDirStats updates directory statistics
-func updateDirStats(dir string, entries []os.DirEntry) {
-	stats := &DirStats{
-		CommonExts: make(map[string]int),
-		LastModified: time.Now(),
-	}
-	
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			stats.FileCount++
-			if info, err := entry.Info(); err == nil {
-				stats.TotalSize += info.Size()
-				ext := strings.ToLower(filepath.Ext(entry.Name()))
-				stats.CommonExts[ext]++
-			}
-		}
-	}
-	
-	fileIndex.Lock()
-	fileIndex.DirStats[dir] = stats
-	fileIndex.Unlock()
-} 
\ No newline at end of file
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package search
+
+import "os"
+
+// dropCache is a no-op where evicting cached pages is not supported
+func dropCache(f *os.File) {}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DirUsage is a node of a disk usage tree
+type DirUsage struct {
+	Path     string
+	DirStats             // Totals for the whole subtree
+	Children []*DirUsage // Sorted by total size, largest first
+}
+
+// TopExts returns up to n extensions of the subtree, most frequent first
+func (d *DirUsage) TopExts(n int) []ExtCount {
+	exts := make([]ExtCount, 0, len(d.CommonExts))
+	for ext, count := range d.CommonExts {
+		exts = append(exts, ExtCount{Ext: ext, Count: count})
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if exts[i].Count != exts[j].Count {
+			return exts[i].Count > exts[j].Count
+		}
+		return exts[i].Ext < exts[j].Ext
+	})
+	if n > 0 && len(exts) > n {
+		exts = exts[:n]
+	}
+	return exts
+}
+
+// DiskUsage walks opts.RootDirs and aggregates the sizes of matching files
+// per directory. Files are totalled as the walk finds them, without being
+// hashed. It returns one tree per root; directories without matching files
+// are left out.
+func DiskUsage(opts SearchOptions) []*DirUsage {
+	roots := cleanRoots(opts.RootDirs)
+	nodes := make(map[string]*DirUsage)
+	var mu sync.Mutex
+
+	node := func(dir string) *DirUsage {
+		n, ok := nodes[dir]
+		if !ok {
+			n = &DirUsage{Path: dir, DirStats: DirStats{CommonExts: make(map[string]int)}}
+			nodes[dir] = n
+		}
+		return n
+	}
+
+	// add adds a file to every directory between it and its root
+	add := func(path string, size int64, modTime time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		root, _, ok := findRoot(path, roots)
+		if !ok {
+			return
+		}
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			node(dir).add(path, size, modTime)
+			if dir == root || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+
+	for _, root := range roots {
+		node(root)
+	}
+
+	opts.onFile = func(path string, info os.FileInfo) {
+		add(path, info.Size(), info.ModTime())
+	}
+	// Only archive entries and errors still arrive as results
+	for result := range Search(opts) {
+		if result.Error == nil {
+			add(result.Path, result.Size, result.ModTime)
+		}
+	}
+
+	isRoot := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		isRoot[root] = true
+	}
+	for dir, n := range nodes {
+		if isRoot[dir] {
+			continue
+		}
+		if parent, ok := nodes[filepath.Dir(dir)]; ok {
+			parent.Children = append(parent.Children, n)
+		}
+	}
+	for _, n := range nodes {
+		sort.Slice(n.Children, func(i, j int) bool {
+			if n.Children[i].TotalSize != n.Children[j].TotalSize {
+				return n.Children[i].TotalSize > n.Children[j].TotalSize
+			}
+			return n.Children[i].Path < n.Children[j].Path
+		})
+	}
+
+	// Keep the order the roots were given in
+	trees := make([]*DirUsage, 0, len(opts.RootDirs))
+	seen := make(map[string]bool)
+	for _, root := range opts.RootDirs {
+		root = filepath.Clean(root)
+		if !seen[root] {
+			seen[root] = true
+			trees = append(trees, nodes[root])
+		}
+	}
+	return trees
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/cespare/xxhash"
+)
+
+// DuplicateGroup is a set of files with identical content
+type DuplicateGroup struct {
+	Size  int64
+	Hash  uint64
+	Files []SearchResult
+}
+
+// Wasted returns the bytes that could be reclaimed by keeping one copy
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// FindDuplicates runs a search and groups found files with identical content.
+// Files are first grouped by size and only same-size candidates are hashed.
+// Groups are sorted by wasted space, largest first.
+func FindDuplicates(opts SearchOptions) []DuplicateGroup {
+	bySize := make(map[int64][]SearchResult)
+	for result := range Search(opts) {
+		if result.Error != nil || result.Size == 0 {
+			continue
+		}
+		bySize[result.Size] = append(bySize[result.Size], result)
+	}
+
+	groups := make([]DuplicateGroup, 0)
+	for size, candidates := range bySize {
+		if len(candidates) < 2 {
+			continue
+		}
+
+		byHash := make(map[uint64][]SearchResult)
+		for _, candidate := range candidates {
+			hash, err := calculateFullHash(candidate.Path)
+			if err != nil {
+				logError("Failed to hash %s: %v", candidate.Path, err)
+				continue
+			}
+			candidate.Hash = hash
+			byHash[hash] = append(byHash[hash], candidate)
+		}
+
+		for hash, files := range byHash {
+			if len(files) < 2 {
+				continue
+			}
+			sort.Slice(files, func(i, j int) bool {
+				return files[i].Path < files[j].Path
+			})
+			groups = append(groups, DuplicateGroup{Size: size, Hash: hash, Files: files})
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Files[0].Path < groups[j].Files[0].Path
+	})
+
+	return groups
+}
+
+// calculateFullHash hashes the entire content of a file
+func calculateFullHash(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	buf := bufferPool.Get().([]byte)
+	defer bufferPool.Put(buf)
+
+	h := xxhash.New()
+	if _, err := io.CopyBuffer(h, f, buf); err != nil {
+		return 0, fmt.Errorf("read failed: %v", err)
+	}
+
+	return h.Sum64(), nil
+}
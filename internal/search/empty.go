@@ -0,0 +1,41 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RemoveEmptyDirs removes directories deepest first, so empty trees reported
+// with SearchOptions.EmptyDirs are removed bottom-up. A directory that is no
+// longer empty is left in place and its error is returned.
+func RemoveEmptyDirs(dirs []string) (int, []error) {
+	sorted := make([]string, len(dirs))
+	copy(sorted, dirs)
+	sort.Slice(sorted, func(i, j int) bool {
+		di := strings.Count(filepath.Clean(sorted[i]), string(filepath.Separator))
+		dj := strings.Count(filepath.Clean(sorted[j]), string(filepath.Separator))
+		if di != dj {
+			return di > dj
+		}
+		return sorted[i] > sorted[j]
+	})
+
+	removed := 0
+	var errs []error
+	for _, dir := range sorted {
+		// os.Remove refuses to delete directories that gained files
+		if err := os.Remove(dir); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to remove directory: %v", err))
+			continue
+		}
+		logInfo("Removed empty directory: %s", dir)
+		removed++
+	}
+	return removed, errs
+}
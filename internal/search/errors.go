@@ -0,0 +1,86 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+)
+
+// ErrorKind classifies why a path could not be read
+type ErrorKind int
+
+const (
+	ErrOther ErrorKind = iota
+	ErrPermissionDenied
+	ErrVanished    // Removed between listing and reading
+	ErrIO          // Device or file system I/O failure
+	ErrPathTooLong // Path exceeds the platform limit
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrPermissionDenied:
+		return "permission denied"
+	case ErrVanished:
+		return "vanished"
+	case ErrIO:
+		return "I/O error"
+	case ErrPathTooLong:
+		return "path too long"
+	default:
+		return "other"
+	}
+}
+
+// WalkError describes a path that could not be read during a search.
+// It is used as SearchResult.Error when SearchOptions.ReportErrors is set.
+type WalkError struct {
+	Kind ErrorKind
+	Op   string // "readdir", "lstat" or "archive"
+	Path string
+	Err  error
+}
+
+func (e *WalkError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %v", e.Op, e.Path, e.Kind, e.Err)
+}
+
+func (e *WalkError) Unwrap() error {
+	return e.Err
+}
+
+// newWalkError wraps err with its classified kind
+func newWalkError(op, path string, err error) *WalkError {
+	return &WalkError{
+		Kind: classifyError(err),
+		Op:   op,
+		Path: path,
+		Err:  err,
+	}
+}
+
+// ErrorKindOf returns the kind of a search error, or ErrOther if err is not a *WalkError
+func ErrorKindOf(err error) ErrorKind {
+	var walkErr *WalkError
+	if errors.As(err, &walkErr) {
+		return walkErr.Kind
+	}
+	return ErrOther
+}
+
+// classifyError maps a file system error to an ErrorKind
+func classifyError(err error) ErrorKind {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermissionDenied
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrVanished
+	case errors.Is(err, syscall.ENAMETOOLONG):
+		return ErrPathTooLong
+	case errors.Is(err, syscall.EIO):
+		return ErrIO
+	default:
+		return ErrOther
+	}
+}
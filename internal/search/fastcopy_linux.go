@@ -0,0 +1,74 @@
+package search
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxKernelCopy is the largest chunk handed to copy_file_range and sendfile
+const maxKernelCopy = 1 << 30
+
+// cloneFile makes the empty dst a FICLONE reflink of src, sharing the data
+// blocks on btrfs, xfs and other copy-on-write file systems. Holes are kept.
+// It reports false when the file system cannot clone between the files.
+func cloneFile(dst, src *os.File) bool {
+	return unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())) == nil
+}
+
+// copyKernel copies up to n bytes from the current offset of src to the
+// current offset of dst without passing them through user space, using
+// copy_file_range, which lets the file system copy or reflink server side,
+// or else sendfile. It returns the number of bytes copied; when that is less
+// than n both offsets are at that position and the caller copies the rest.
+func copyKernel(dst, src *os.File, n int64) (int64, error) {
+	var written int64
+	for written < n {
+		c, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, kernelChunk(n-written), 0)
+		if err != nil {
+			if written == 0 && fastCopyUnsupported(err) {
+				break
+			}
+			return written, err
+		}
+		if c == 0 {
+			break
+		}
+		written += int64(c)
+	}
+	if written > 0 {
+		return written, nil
+	}
+
+	for written < n {
+		c, err := unix.Sendfile(int(dst.Fd()), int(src.Fd()), nil, kernelChunk(n-written))
+		if err != nil {
+			if written == 0 && fastCopyUnsupported(err) {
+				break
+			}
+			return written, err
+		}
+		if c == 0 {
+			break
+		}
+		written += int64(c)
+	}
+	return written, nil
+}
+
+// kernelChunk limits a remaining byte count to maxKernelCopy
+func kernelChunk(remaining int64) int {
+	if remaining > maxKernelCopy {
+		return maxKernelCopy
+	}
+	return int(remaining)
+}
+
+// fastCopyUnsupported reports whether err means the kernel or file system
+// cannot copy between these files, so the buffered copy should be used
+func fastCopyUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL) ||
+		errors.Is(err, unix.EPERM)
+}
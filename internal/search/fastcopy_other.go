@@ -0,0 +1,15 @@
+//go:build !linux
+
+package search
+
+import "os"
+
+// cloneFile cannot clone files on this platform
+func cloneFile(dst, src *os.File) bool {
+	return false
+}
+
+// copyKernel copies nothing on this platform, so copies are always buffered
+func copyKernel(dst, src *os.File, n int64) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// TimeField selects which file timestamp a TimeFilter compares
+type TimeField int
+
+const (
+	ModifiedTime TimeField = iota // mtime
+	ChangedTime                   // ctime, inode change time (not available on Windows)
+	AccessedTime                  // atime
+)
+
+func (f TimeField) String() string {
+	switch f {
+	case ChangedTime:
+		return "ctime"
+	case AccessedTime:
+		return "atime"
+	default:
+		return "mtime"
+	}
+}
+
+// TimeFilter restricts one timestamp to the range After < t < Before.
+// A zero bound leaves that side open.
+type TimeFilter struct {
+	Field  TimeField
+	After  time.Time
+	Before time.Time
+}
+
+// FileTime returns the selected timestamp of path, following symlinks.
+// It is useful for "newer than this reference file" filters.
+func FileTime(path string, field TimeField) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := fileTime(info, field)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s is not available for %s on this platform", field, path)
+	}
+	return t, nil
+}
+
+// fileTime returns the selected timestamp from a stat result
+func fileTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	if field == ModifiedTime {
+		return info.ModTime(), true
+	}
+	return statTime(info, field)
+}
+
+// matchesTimeFilters checks every time filter against the stat result
+func matchesTimeFilters(info os.FileInfo, filters []TimeFilter) bool {
+	for _, f := range filters {
+		t, ok := fileTime(info, f.Field)
+		if !ok || !f.contains(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains reports whether t lies inside the filter range
+func (f TimeFilter) contains(t time.Time) bool {
+	if !f.After.IsZero() && !t.After(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && !t.Before(f.Before) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,22 @@
+package search
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statTime reads ctime or atime from the platform stat data
+func statTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch field {
+	case ChangedTime:
+		return time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec)), true
+	case AccessedTime:
+		return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), true
+	}
+	return time.Time{}, false
+}
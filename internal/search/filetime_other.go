@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+
+package search
+
+import (
+	"os"
+	"time"
+)
+
+// statTime reports that ctime and atime are not available on this platform
+func statTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	return time.Time{}, false
+}
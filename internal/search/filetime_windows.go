@@ -0,0 +1,16 @@
+package search
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statTime reads atime from the platform stat data; Windows has no ctime
+func statTime(info os.FileInfo, field TimeField) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok || field != AccessedTime {
+		return time.Time{}, false
+	}
+	return time.Unix(0, data.LastAccessTime.Nanoseconds()), true
+}
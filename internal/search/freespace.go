@@ -0,0 +1,99 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SpaceError reports that a batch of file operations does not fit on the
+// target file system
+type SpaceError struct {
+	Dir       string
+	Required  int64
+	Available int64
+}
+
+func (e *SpaceError) Error() string {
+	return fmt.Sprintf("not enough space in %s: %d bytes needed, %d available", e.Dir, e.Required, e.Available)
+}
+
+// CheckFreeSpace compares the space that copying or moving paths into
+// opts.TargetDir needs with the space available there, so a batch does not
+// fail halfway with a full disk. Sparse files count with their allocated
+// size, and moves within the target file system need no space. Other
+// operations need none. It returns a *SpaceError when the files do not fit;
+// when the free space cannot be determined the check is skipped.
+func CheckFreeSpace(paths []string, opts FileOperationOptions) error {
+	if opts.Operation != CopyFiles && opts.Operation != MoveFiles {
+		return nil
+	}
+
+	dir := existingDir(opts.TargetDir)
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+
+	var required int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if opts.Operation == MoveFiles && sameDevice(info, dirInfo) {
+			continue
+		}
+		required += allocatedSize(info)
+	}
+	if required == 0 {
+		return nil
+	}
+
+	available, err := freeSpaceTimeout(dir, 5*time.Second)
+	if err != nil {
+		logError("Skipping free space check for %s: %v", dir, err)
+		return nil
+	}
+	if required > available {
+		return &SpaceError{Dir: dir, Required: required, Available: available}
+	}
+	return nil
+}
+
+// freeSpaceTimeout calls freeSpace, giving up after timeout since statfs
+// can hang on unreachable network mounts
+func freeSpaceTimeout(dir string, timeout time.Duration) (int64, error) {
+	type result struct {
+		available int64
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		available, err := freeSpace(dir)
+		done <- result{available, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.available, r.err
+	case <-time.After(timeout):
+		return 0, fmt.Errorf("free space check timed out")
+	}
+}
+
+// existingDir returns dir or its nearest existing parent
+func existingDir(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package search
+
+import (
+	"fmt"
+	"os"
+)
+
+// freeSpace is not supported on this platform
+func freeSpace(dir string) (int64, error) {
+	return 0, fmt.Errorf("free space check not supported")
+}
+
+// allocatedSize returns the size of a file
+func allocatedSize(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+// sameDevice assumes files are on different file systems
+func sameDevice(a, b os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd
+
+package search
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// freeSpace returns the bytes available to unprivileged users on the file
+// system of dir
+func freeSpace(dir string) (int64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// allocatedSize returns the bytes a file occupies on disk, which is less
+// than its size for sparse files
+func allocatedSize(info os.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int64(stat.Blocks)*512 < info.Size() {
+		return int64(stat.Blocks) * 512
+	}
+	return info.Size()
+}
+
+// sameDevice reports whether two files are on the same file system
+func sameDevice(a, b os.FileInfo) bool {
+	sa, ok := a.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	sb, ok := b.Sys().(*syscall.Stat_t)
+	return ok && sa.Dev == sb.Dev
+}
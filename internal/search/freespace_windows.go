@@ -0,0 +1,32 @@
+package search
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeSpace returns the bytes available to the current user on the volume
+// of dir
+func freeSpace(dir string) (int64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
+
+// allocatedSize returns the size of a file
+func allocatedSize(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+// sameDevice reports whether two files are on the same volume. Without a
+// cheap way to tell, files are assumed to be on different volumes.
+func sameDevice(a, b os.FileInfo) bool {
+	return false
+}
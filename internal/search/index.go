@@ -0,0 +1,209 @@
+package search
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexStats summarizes the contents of a file index
+type IndexStats struct {
+	Files     int
+	Dirs      int
+	TotalSize int64
+	LastBuild time.Time
+	TopExts   []ExtCount // Most common extensions, descending
+}
+
+// ExtCount is the number of files with a given extension
+type ExtCount struct {
+	Ext   string
+	Count int
+}
+
+// indexSnapshot is the on-disk form of FileIndex (the mutex is not encodable)
+type indexSnapshot struct {
+	Files     map[string]*FileMetadata
+	DirStats  map[string]*DirStats
+	LastBuild time.Time
+}
+
+// BuildIndex runs a search with the given options and records every found file
+func BuildIndex(opts SearchOptions) *FileIndex {
+	index := &FileIndex{
+		Files:    make(map[string]*FileMetadata),
+		DirStats: make(map[string]*DirStats),
+	}
+
+	for result := range Search(opts) {
+		if result.Error != nil {
+			continue
+		}
+
+		index.Files[result.Path] = &FileMetadata{
+			Size:    result.Size,
+			ModTime: result.ModTime,
+		}
+
+		addDirStats(index.DirStats, filepath.Dir(result.Path), result)
+	}
+
+	index.LastBuild = time.Now()
+	return index
+}
+
+// SaveIndex writes the index to path atomically
+func SaveIndex(index *FileIndex, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %v", err)
+	}
+
+	index.RLock()
+	defer index.RUnlock()
+	snapshot := indexSnapshot{
+		Files:     index.Files,
+		DirStats:  index.DirStats,
+		LastBuild: index.LastBuild,
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create index file: %v", err)
+	}
+
+	if err := gob.NewEncoder(f).Encode(&snapshot); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to encode index: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close index file: %v", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// LoadIndex reads an index previously written by SaveIndex
+func LoadIndex(path string) (*FileIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index: %v", err)
+	}
+	defer f.Close()
+
+	var snapshot indexSnapshot
+	if err := gob.NewDecoder(f).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %v", err)
+	}
+
+	index := &FileIndex{
+		Files:     snapshot.Files,
+		DirStats:  snapshot.DirStats,
+		LastBuild: snapshot.LastBuild,
+	}
+	if index.Files == nil {
+		index.Files = make(map[string]*FileMetadata)
+	}
+	if index.DirStats == nil {
+		index.DirStats = make(map[string]*DirStats)
+	}
+
+	return index, nil
+}
+
+// Query returns indexed files matching the patterns, extensions and
+// size/age constraints in opts, restricted to opts.RootDirs when set
+func (idx *FileIndex) Query(opts SearchOptions) []SearchResult {
+	patterns := preparePatterns(opts)
+
+	idx.RLock()
+	defer idx.RUnlock()
+
+	results := make([]SearchResult, 0)
+	for path, meta := range idx.Files {
+		if !underAnyRoot(path, opts.RootDirs) {
+			continue
+		}
+		if !matchesPatterns(path, patterns, opts.IgnoreCase) ||
+			!matchesSizeAndAge(meta.Size, meta.ModTime, opts) ||
+			!matchesIndexedTime(meta.ModTime, opts.TimeFilters) {
+			continue
+		}
+		results = append(results, SearchResult{
+			Path:    path,
+			Size:    meta.Size,
+			ModTime: meta.ModTime,
+			UID:     -1,
+			GID:     -1,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results
+}
+
+// Stats summarizes the index contents
+func (idx *FileIndex) Stats() IndexStats {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	stats := IndexStats{
+		Files:     len(idx.Files),
+		Dirs:      len(idx.DirStats),
+		LastBuild: idx.LastBuild,
+	}
+
+	exts := make(map[string]int)
+	for _, dir := range idx.DirStats {
+		stats.TotalSize += dir.TotalSize
+		for ext, count := range dir.CommonExts {
+			exts[ext] += count
+		}
+	}
+
+	for ext, count := range exts {
+		stats.TopExts = append(stats.TopExts, ExtCount{Ext: ext, Count: count})
+	}
+	sort.Slice(stats.TopExts, func(i, j int) bool {
+		if stats.TopExts[i].Count != stats.TopExts[j].Count {
+			return stats.TopExts[i].Count > stats.TopExts[j].Count
+		}
+		return stats.TopExts[i].Ext < stats.TopExts[j].Ext
+	})
+
+	return stats
+}
+
+// underAnyRoot reports whether path lies under one of roots (or roots is empty)
+func underAnyRoot(path string, roots []string) bool {
+	if len(roots) == 0 {
+		return true
+	}
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesIndexedTime checks time filters against an indexed file. The index
+// only stores mtime, so ctime and atime filters match nothing.
+func matchesIndexedTime(modTime time.Time, filters []TimeFilter) bool {
+	for _, f := range filters {
+		if f.Field != ModifiedTime || !f.contains(modTime) {
+			return false
+		}
+	}
+	return true
+}
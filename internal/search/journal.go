@@ -0,0 +1,383 @@
+package search
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// journalUndo marks an entry of a journal as undone
+	journalUndo = "undo"
+
+	// journalLost marks the latest earlier entry with the same target as
+	// lost, such as a file in an archive part that was discarded
+	journalLost = "lost"
+)
+
+// String returns the short name of an operation as used in journals
+func (op FileOperation) String() string {
+	switch op {
+	case CopyFiles:
+		return "copy"
+	case MoveFiles:
+		return "move"
+	case DeleteFiles:
+		return "delete"
+	case ArchiveFiles:
+		return "archive"
+	case TrashFiles:
+		return "trash"
+	default:
+		return "none"
+	}
+}
+
+// JournalEntry records one executed file operation
+type JournalEntry struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"op"`
+	Source    string    `json:"source,omitempty"`
+	Target    string    `json:"target,omitempty"` // Copy, move or trash location; archive entries use virtual paths
+	Size      int64     `json:"size"`
+	Hash      string    `json:"hash,omitempty"`       // xxhash of the content, hex
+	TrashInfo string    `json:"trash_info,omitempty"` // .trashinfo file of trashed files
+	Replaced  bool      `json:"replaced,omitempty"`   // The copy or move overwrote an existing target
+	Undoes    int       `json:"undoes,omitempty"`     // Line of the entry an undo entry reverses
+}
+
+// JournalSummary describes a journal for listings
+type JournalSummary struct {
+	ID          string
+	Time        time.Time // Time of the first entry
+	Operation   string
+	Files       int
+	Bytes       int64
+	Undone      bool // Every entry was undone
+	UndoneFiles int
+}
+
+// Journal is an append-only log of the operations of one run, stored as
+// JSON lines in <dir>/<id>.jsonl. The file is created with the first entry,
+// so runs that change nothing leave no journal. It is safe for concurrent use.
+type Journal struct {
+	mu   sync.Mutex
+	dir  string
+	id   string
+	file *os.File
+}
+
+// DefaultJournalDir returns the journal directory in the user configuration
+// directory
+func DefaultJournalDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %v", err)
+	}
+	return filepath.Join(dir, "koe", "journal"), nil
+}
+
+// NewJournal prepares a journal with a new ID in dir
+func NewJournal(dir string) (*Journal, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate journal id: %v", err)
+	}
+	return &Journal{
+		dir: dir,
+		id:  fmt.Sprintf("%s-%x", time.Now().Format("20060102-150405"), suffix),
+	}, nil
+}
+
+// ID returns the journal ID used by UndoJournal
+func (j *Journal) ID() string {
+	return j.id
+}
+
+// Empty reports whether nothing has been recorded yet
+func (j *Journal) Empty() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file == nil
+}
+
+// Close syncs and closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Sync()
+	if cerr := j.file.Close(); err == nil {
+		err = cerr
+	}
+	j.file = nil
+	return err
+}
+
+// record appends an entry, creating the journal file on first use
+func (j *Journal) record(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		if err := os.MkdirAll(j.dir, 0700); err != nil {
+			return fmt.Errorf("failed to create journal directory: %v", err)
+		}
+		f, err := os.OpenFile(journalPath(j.dir, j.id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create journal: %v", err)
+		}
+		j.file = f
+	}
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// journalPath returns the file of a journal
+func journalPath(dir, id string) string {
+	return filepath.Join(dir, id+".jsonl")
+}
+
+// ReadJournal returns the entries of a journal in the order they were written
+func ReadJournal(dir, id string) ([]JournalEntry, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid journal id: %q", id)
+	}
+	f, err := os.Open(journalPath(dir, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %v", err)
+	}
+	defer f.Close()
+
+	entries := make([]JournalEntry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("corrupt journal %s: %v", id, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %v", err)
+	}
+	return entries, nil
+}
+
+// ListJournals summarizes the journals in dir, newest first
+func ListJournals(dir string) ([]JournalSummary, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]JournalSummary, 0, len(names))
+	for _, name := range names {
+		id := strings.TrimSuffix(filepath.Base(name), ".jsonl")
+		entries, err := ReadJournal(dir, id)
+		if err != nil {
+			logError("Skipping journal %s: %v", id, err)
+			continue
+		}
+
+		s := JournalSummary{ID: id}
+		undone := undoneEntries(entries)
+		lost := lostEntries(entries)
+		for i, e := range entries {
+			if e.Operation == journalUndo || e.Operation == journalLost || lost[i+1] {
+				continue
+			}
+			if s.Files == 0 {
+				s.Time = e.Time
+				s.Operation = e.Operation
+			}
+			s.Files++
+			s.Bytes += e.Size
+			if undone[i+1] {
+				s.UndoneFiles++
+			}
+		}
+		s.Undone = s.Files > 0 && s.UndoneFiles == s.Files
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].Time.Equal(summaries[j].Time) {
+			return summaries[i].Time.After(summaries[j].Time)
+		}
+		return summaries[i].ID > summaries[j].ID
+	})
+	return summaries, nil
+}
+
+// UndoJournal reverses the operations of a journal, newest first: moved
+// files are moved back, trashed files restored and copies removed. Files
+// changed since the operation are left alone, as are deletions, archive
+// entries and copies that overwrote a file, which cannot be undone. Every
+// reversed entry is marked in the journal, so running it again retries only
+// the entries that failed.
+func UndoJournal(dir, id string) (int, []error) {
+	entries, err := ReadJournal(dir, id)
+	if err != nil {
+		return 0, []error{err}
+	}
+	done := undoneEntries(entries)
+	lost := lostEntries(entries)
+
+	j := &Journal{dir: dir, id: id}
+	undone, pending := 0, 0
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		line := i + 1
+		if entries[i].Operation == journalUndo || entries[i].Operation == journalLost || done[line] || lost[line] {
+			continue
+		}
+		pending++
+		if err := undoEntry(entries[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		undone++
+
+		// Record the undo so it is part of the audit trail and not repeated
+		if err := j.record(JournalEntry{Time: time.Now(), Operation: journalUndo, Undoes: line}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark %s as undone: %v", entries[i].Source, err))
+		}
+	}
+	if err := j.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if pending == 0 {
+		return 0, []error{fmt.Errorf("journal %s was already undone", id)}
+	}
+	return undone, errs
+}
+
+// undoneEntries returns the lines (counted from 1) of the entries that have
+// been undone. An undo entry without a line marks the whole journal.
+func undoneEntries(entries []JournalEntry) map[int]bool {
+	done := make(map[int]bool)
+	for _, e := range entries {
+		if e.Operation != journalUndo {
+			continue
+		}
+		if e.Undoes > 0 {
+			done[e.Undoes] = true
+			continue
+		}
+		for i := range entries {
+			done[i+1] = true
+		}
+	}
+	return done
+}
+
+// lostEntries returns the lines (counted from 1) of the entries that a later
+// lost entry marks
+func lostEntries(entries []JournalEntry) map[int]bool {
+	lost := make(map[int]bool)
+	for i, e := range entries {
+		if e.Operation != journalLost {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			op := entries[j].Operation
+			if op != journalUndo && op != journalLost && !lost[j+1] && entries[j].Target == e.Target {
+				lost[j+1] = true
+				break
+			}
+		}
+	}
+	return lost
+}
+
+// recordDone journals an operation that was done, with the hash of the
+// source content
+func (j *Journal) recordDone(entry JournalEntry, hash uint64) error {
+	entry.Time = time.Now()
+	entry.Hash = fmt.Sprintf("%016x", hash)
+	if err := j.record(entry); err != nil {
+		return fmt.Errorf("operation done but not journaled: %v", err)
+	}
+	return nil
+}
+
+// undoEntry reverses a single journal entry
+func undoEntry(e JournalEntry) error {
+	switch e.Operation {
+	case "move":
+		if err := checkUnchanged(e.Target, e); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(e.Source); err == nil {
+			return fmt.Errorf("cannot move %s back: %s exists", e.Target, e.Source)
+		}
+		if err := os.MkdirAll(filepath.Dir(e.Source), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %v", err)
+		}
+		info, err := os.Stat(e.Target)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(e.Target, e.Source); err == nil {
+			return nil
+		}
+		// Different file systems: copy back with attributes and verification
+		opts := FileOperationOptions{PreserveAttributes: PreserveAll, Verify: true}
+		if err := copyWithAttributes(e.Target, e.Source, info, opts); err != nil {
+			return fmt.Errorf("failed to move %s back: %v", e.Target, err)
+		}
+		return os.Remove(e.Target)
+	case "copy":
+		if e.Replaced {
+			return fmt.Errorf("cannot undo copy of %s: it overwrote %s, whose earlier content is gone", e.Source, e.Target)
+		}
+		if err := checkUnchanged(e.Target, e); err != nil {
+			return err
+		}
+		return os.Remove(e.Target)
+	case "trash":
+		_, err := RestoreTrashed(TrashedFile{
+			OriginalPath: e.Source,
+			TrashPath:    e.Target,
+			InfoPath:     e.TrashInfo,
+		}, Skip)
+		return err
+	default:
+		return fmt.Errorf("cannot undo %s of %s", e.Operation, e.Source)
+	}
+}
+
+// checkUnchanged verifies that path still holds the content recorded in e
+func checkUnchanged(path string, e JournalEntry) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot undo %s of %s: %v", e.Operation, e.Source, err)
+	}
+	if info.Size() != e.Size {
+		return fmt.Errorf("cannot undo %s of %s: %s changed", e.Operation, e.Source, path)
+	}
+	if e.Hash != "" {
+		hash, err := calculateFullHash(path)
+		if err != nil {
+			return fmt.Errorf("cannot undo %s of %s: %v", e.Operation, e.Source, err)
+		}
+		if fmt.Sprintf("%016x", hash) != e.Hash {
+			return fmt.Errorf("cannot undo %s of %s: %s changed", e.Operation, e.Source, path)
+		}
+	}
+	return nil
+}
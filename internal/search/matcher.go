@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 	"github.com/cespare/xxhash"
 )
 
@@ -138,6 +139,10 @@ func shouldProcessFile(path string, opts SearchOptions) bool {
 	if len(opts.Patterns) == 0 {
 		return true
 	}
+	// Archives may contain matching entries whatever their own name is
+	if opts.SearchArchives && archiveKindOf(path) != notArchive {
+		return true
+	}
 	
 	filename := filepath.Base(path)
 	
@@ -162,18 +167,48 @@ func shouldProcessFile(path string, opts SearchOptions) bool {
 	return false
 }
 
-// matchesFileConstraints checks if file matches size and age constraints
-func matchesFileConstraints(_ os.FileInfo, _ SearchOptions) bool {
+// matchesFileConstraints checks if file matches size, age, date, owner and permission constraints
+func matchesFileConstraints(info os.FileInfo, opts SearchOptions) bool {
+	return matchesSizeAndAge(info.Size(), info.ModTime(), opts) &&
+		matchesTimeFilters(info, opts.TimeFilters) &&
+		matchesOwnerAndPerm(info, opts)
+}
+
+// matchesSizeAndAge checks size and modification time against the options
+func matchesSizeAndAge(size int64, modTime time.Time, opts SearchOptions) bool {
+	if opts.EmptyFiles && size != 0 {
+		return false
+	}
+	if opts.MinSize > 0 && size < opts.MinSize {
+		return false
+	}
+	if opts.MaxSize > 0 && size > opts.MaxSize {
+		return false
+	}
+	
+	if opts.MinAge > 0 || opts.MaxAge > 0 {
+		age := time.Since(modTime)
+		if opts.MinAge > 0 && age < opts.MinAge {
+			return false
+		}
+		if opts.MaxAge > 0 && age > opts.MaxAge {
+			return false
+		}
+	}
+	
 	return true
 }
 
 // processByMMap processes a file using memory mapping
 func processByMMap(path string, info os.FileInfo, _ compiledPatterns, _ SearchOptions, processor *resultProcessor) error {
+	uid, gid, _ := fileOwner(info)
 	processor.add(SearchResult{
 		Path:    path,
 		Size:    info.Size(),
 		Mode:    info.Mode(),
 		ModTime: info.ModTime(),
+		UID:     uid,
+		GID:     gid,
 	})
 	return nil
 }
@@ -0,0 +1,82 @@
+package search
+
+import "sync"
+
+// OperationStatus is the outcome of a file operation on one file
+type OperationStatus int
+
+const (
+	OpDone OperationStatus = iota
+	OpSkipped
+	OpFailed
+)
+
+func (s OperationStatus) String() string {
+	switch s {
+	case OpSkipped:
+		return "skipped"
+	case OpFailed:
+		return "failed"
+	default:
+		return "done"
+	}
+}
+
+// OperationResult reports what happened to one file
+type OperationResult struct {
+	Path      string
+	Operation FileOperation
+	Status    OperationStatus
+	Target    string // Where the file went; empty for delete, skip and failure
+	Bytes     int64  // Bytes copied, moved, archived, trashed or deleted
+	Reason    string // Why the file was skipped
+	Err       error  // Why the operation failed
+	Revised   bool   // Replaces an earlier done result of the file, see Archiver.Lost
+}
+
+// OperationSummary totals the results of a batch of file operations. It is
+// safe for concurrent use.
+type OperationSummary struct {
+	mu       sync.Mutex
+	done     int
+	skipped  int
+	failed   int
+	bytes    int64
+	failures []OperationResult
+}
+
+// Add counts a result
+func (s *OperationSummary) Add(r OperationResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Status {
+	case OpDone:
+		s.done++
+		s.bytes += r.Bytes
+	case OpSkipped:
+		s.skipped++
+	case OpFailed:
+		if r.Revised {
+			// Counted as done before
+			s.done--
+			s.bytes -= r.Bytes
+		}
+		s.failed++
+		s.failures = append(s.failures, r)
+	}
+}
+
+// Counts returns the number of done, skipped and failed files and the bytes
+// of the files that were done
+func (s *OperationSummary) Counts() (done, skipped, failed int, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done, s.skipped, s.failed, s.bytes
+}
+
+// Failures returns the failed results in the order they were added
+func (s *OperationSummary) Failures() []OperationResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]OperationResult(nil), s.failures...)
+}
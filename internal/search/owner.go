@@ -0,0 +1,82 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// LookupOwner resolves a user name or numeric uid to a uid
+func LookupOwner(owner string) (int, error) {
+	if id, err := strconv.Atoi(owner); err == nil && id >= 0 {
+		return id, nil
+	}
+	u, err := user.Lookup(owner)
+	if err != nil {
+		return -1, fmt.Errorf("unknown owner %q: %v", owner, err)
+	}
+	id, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return -1, fmt.Errorf("owner %q has no numeric uid", owner)
+	}
+	return id, nil
+}
+
+// LookupGroup resolves a group name or numeric gid to a gid
+func LookupGroup(group string) (int, error) {
+	if id, err := strconv.Atoi(group); err == nil && id >= 0 {
+		return id, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return -1, fmt.Errorf("unknown group %q: %v", group, err)
+	}
+	id, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return -1, fmt.Errorf("group %q has no numeric gid", group)
+	}
+	return id, nil
+}
+
+// resolveOwnerFilters looks up Owner and Group once per search. A name that
+// cannot be resolved leaves an id of -1, which matches no file.
+func resolveOwnerFilters(opts *SearchOptions) {
+	opts.ownerID, opts.groupID = -1, -1
+	if opts.Owner != "" {
+		id, err := LookupOwner(opts.Owner)
+		if err != nil {
+			logError("Owner filter matches nothing: %v", err)
+		}
+		opts.ownerID = id
+	}
+	if opts.Group != "" {
+		id, err := LookupGroup(opts.Group)
+		if err != nil {
+			logError("Group filter matches nothing: %v", err)
+		}
+		opts.groupID = id
+	}
+}
+
+// matchesOwnerAndPerm checks the owner, group and permission filters
+func matchesOwnerAndPerm(info os.FileInfo, opts SearchOptions) bool {
+	if opts.PermMask != 0 && info.Mode()&opts.PermMask != opts.Perm {
+		return false
+	}
+	if opts.Owner == "" && opts.Group == "" {
+		return true
+	}
+
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return false
+	}
+	if opts.Owner != "" && uid != opts.ownerID {
+		return false
+	}
+	if opts.Group != "" && gid != opts.groupID {
+		return false
+	}
+	return true
+}
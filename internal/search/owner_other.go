@@ -0,0 +1,10 @@
+//go:build !unix
+
+package search
+
+import "os"
+
+// fileOwner reports that file ownership is not available on this platform
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return -1, -1, false
+}
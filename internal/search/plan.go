@@ -0,0 +1,152 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// PlanAction is what a file operation would do with one file
+type PlanAction int
+
+const (
+	PlanSkip PlanAction = iota
+	PlanCopy
+	PlanMove
+	PlanDelete
+	PlanArchive
+	PlanTrash
+)
+
+func (a PlanAction) String() string {
+	switch a {
+	case PlanCopy:
+		return "copy"
+	case PlanMove:
+		return "move"
+	case PlanDelete:
+		return "delete"
+	case PlanArchive:
+		return "archive"
+	case PlanTrash:
+		return "trash"
+	default:
+		return "skip"
+	}
+}
+
+// PlanEntry describes what a file operation would do with one source file
+type PlanEntry struct {
+	Source string
+	Target string // Resolved target path; empty for delete and skip
+	Action PlanAction
+	Bytes  int64  // Bytes that would be written, moved or freed
+	Reason string // Why the file would be skipped
+}
+
+// OperationPlan collects the entries of a dry run. Targets that earlier
+// entries would create count as existing, so conflicts between the planned
+// files resolve as they would during the real run. It is safe for
+// concurrent use.
+type OperationPlan struct {
+	mu      sync.Mutex
+	entries []PlanEntry
+	claimed map[string]bool
+}
+
+// NewOperationPlan creates an empty plan
+func NewOperationPlan() *OperationPlan {
+	return &OperationPlan{claimed: make(map[string]bool)}
+}
+
+// Entries returns a copy of the planned entries in the order they were added
+func (p *OperationPlan) Entries() []PlanEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlanEntry(nil), p.entries...)
+}
+
+// Totals returns the number of files that would be processed, their bytes
+// and the number of files that would be skipped
+func (p *OperationPlan) Totals() (files int, bytes int64, skipped int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.entries {
+		if e.Action == PlanSkip {
+			skipped++
+			continue
+		}
+		files++
+		bytes += e.Bytes
+	}
+	return files, bytes, skipped
+}
+
+// PlanFileOperation works out what HandleFileOperation would do with path
+// without touching the file system. When opts.Plan is set the entry is added
+// to it and conflicts are resolved against its earlier entries.
+func PlanFileOperation(path string, opts FileOperationOptions) (PlanEntry, error) {
+	entry := PlanEntry{Source: path}
+	if isArchiveEntry(path) {
+		entry.Reason = archiveEntryReason
+		if opts.Plan != nil {
+			opts.Plan.mu.Lock()
+			opts.Plan.entries = append(opts.Plan.entries, entry)
+			opts.Plan.mu.Unlock()
+		}
+		return entry, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return entry, fmt.Errorf("failed to get source file info: %v", err)
+	}
+	entry.Bytes = info.Size()
+
+	var p *OperationPlan
+	if opts.Plan != nil {
+		p = opts.Plan
+		p.mu.Lock()
+		defer p.mu.Unlock()
+	}
+
+	switch opts.Operation {
+	case NoOperation:
+		entry.Reason = "no operation"
+	case DeleteFiles:
+		entry.Action = PlanDelete
+	case TrashFiles:
+		entry.Action = PlanTrash
+	case ArchiveFiles:
+		entry.Action = PlanArchive
+		entry.Target = opts.TargetDir
+	case CopyFiles, MoveFiles:
+		exists := pathExists
+		if p != nil {
+			exists = func(path string) bool { return p.claimed[path] || pathExists(path) }
+		}
+		target := resolveConflictWith(targetPath(path, opts), opts.ConflictPolicy, exists)
+		if target == "" {
+			entry.Reason = "target exists"
+			break
+		}
+		entry.Target = target
+		entry.Action = PlanCopy
+		if opts.Operation == MoveFiles {
+			entry.Action = PlanMove
+		}
+		if p != nil {
+			p.claimed[target] = true
+		}
+	default:
+		return entry, fmt.Errorf("unknown operation: %v", opts.Operation)
+	}
+
+	if entry.Action == PlanSkip {
+		entry.Bytes = 0
+	}
+	if p != nil {
+		p.entries = append(p.entries, entry)
+	}
+	return entry, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
@@ -12,13 +13,17 @@ import (
 	"runtime"
 	"time"
 	"sync/atomic"
+
+	"github.com/cespare/xxhash"
 )
 
 // ProcessorOptions configures the file operation processor
 type ProcessorOptions struct {
-	Workers          int
-	MaxQueueSize     int
-	ThrottleInterval time.Duration
+	Workers         int
+	MaxQueueSize    int                   // Add blocks while this many operations are waiting
+	MaxOpsPerSecond int64                 // Limit on started operations per second (0 = unlimited)
+	Context         context.Context       // Cancels operations that have not started yet (optional)
+	OnResult        func(OperationResult) // Called from the workers for every queued file
 }
 
 // processRegularFile processes a regular file
@@ -73,47 +78,128 @@ func processRegularFile(path string, info os.FileInfo, patterns compiledPatterns
 
 // HandleFileOperation processes the file according to the specified operation
 func HandleFileOperation(path string, opts FileOperationOptions) error {
+	return RunFileOperation(path, opts).Err
+}
+
+// RunFileOperation processes the file like HandleFileOperation and reports
+// whether it was done, skipped or failed
+func RunFileOperation(path string, opts FileOperationOptions) OperationResult {
+	result := OperationResult{Path: path, Operation: opts.Operation}
+	target, bytes, skipped, err := applyFileOperation(path, opts)
+	switch {
+	case err != nil:
+		result.Status = OpFailed
+		result.Err = err
+	case skipped != "":
+		result.Status = OpSkipped
+		result.Reason = skipped
+	default:
+		result.Status = OpDone
+		result.Target = target
+		result.Bytes = bytes
+	}
+	return result
+}
+
+// applyFileOperation performs the operation and returns the target, the
+// bytes processed and, for skipped files, the reason
+func applyFileOperation(path string, opts FileOperationOptions) (target string, bytes int64, skipped string, err error) {
 	if opts.Operation == NoOperation {
-		return nil
+		return "", 0, "no operation", nil
+	}
+	if opts.DryRun {
+		entry, err := PlanFileOperation(path, opts)
+		if err != nil || entry.Action == PlanSkip {
+			return "", 0, entry.Reason, err
+		}
+		return entry.Target, entry.Bytes, "", nil
+	}
+	if isArchiveEntry(path) {
+		return "", 0, archiveEntryReason, nil
 	}
 
 	// Get file info for source
 	srcInfo, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("failed to get source file info: %v", err)
+		return "", 0, "", fmt.Errorf("failed to get source file info: %v", err)
 	}
 
 	// Check if file is accessible
 	if err := checkFileAccess(path); err != nil {
-		return fmt.Errorf("file is not accessible: %v", err)
+		return "", 0, "", fmt.Errorf("file is not accessible: %v", err)
 	}
 
 	// Create target directory if it doesn't exist
-	if opts.Operation != DeleteFiles {
+	if opts.Operation != DeleteFiles && opts.Operation != TrashFiles {
 		if err := os.MkdirAll(opts.TargetDir, 0755); err != nil {
-			return fmt.Errorf("failed to create target directory: %v", err)
+			return "", 0, "", fmt.Errorf("failed to create target directory: %v", err)
 		}
 
 		// Check if target directory is writable
 		if err := checkDirWritable(opts.TargetDir); err != nil {
-			return fmt.Errorf("target directory is not writable: %v", err)
+			return "", 0, "", fmt.Errorf("target directory is not writable: %v", err)
 		}
 	}
 
+	// Hash the content before it moves or disappears
+	var hash uint64
+	if opts.Journal != nil {
+		if hash, err = calculateFullHash(path); err != nil {
+			return "", 0, "", fmt.Errorf("failed to hash source: %v", err)
+		}
+	}
+
+	entry := JournalEntry{Operation: opts.Operation.String(), Source: path, Size: srcInfo.Size()}
+	if opts.ConflictPolicy == Overwrite && (opts.Operation == CopyFiles || opts.Operation == MoveFiles) {
+		// The overwritten file is gone for good, which undo has to know
+		entry.Replaced = pathExists(targetPath(path, opts))
+	}
 	switch opts.Operation {
 	case CopyFiles:
-		return copyFile(path, opts, srcInfo)
+		entry.Target, err = copyFile(path, opts, srcInfo)
 	case MoveFiles:
-		return moveFile(path, opts, srcInfo)
+		entry.Target, err = moveFile(path, opts, srcInfo)
+	case ArchiveFiles:
+		if opts.Archiver == nil {
+			return "", 0, "", fmt.Errorf("archive operation requires an archiver")
+		}
+		// The archiver journals the entry, in order with the lost entries
+		// it records when a part has to be discarded
+		var record func(target string) error
+		if opts.Journal != nil {
+			record = func(target string) error {
+				entry.Target = target
+				return opts.Journal.recordDone(entry, hash)
+			}
+		}
+		entry.Target, err = opts.Archiver.add(path, record)
+	case TrashFiles:
+		var trashed TrashedFile
+		trashed, err = TrashFile(path)
+		entry.Target, entry.TrashInfo = trashed.TrashPath, trashed.InfoPath
 	case DeleteFiles:
 		// Check if file is writable before attempting to delete
 		if err := checkFileWritable(path); err != nil {
-			return fmt.Errorf("file is not writable: %v", err)
+			return "", 0, "", fmt.Errorf("file is not writable: %v", err)
 		}
-		return os.Remove(path)
+		err = os.Remove(path)
 	default:
-		return fmt.Errorf("unknown operation: %v", opts.Operation)
+		return "", 0, "", fmt.Errorf("unknown operation: %v", opts.Operation)
+	}
+	if err != nil {
+		return "", 0, "", err
 	}
+
+	// Copies and moves skipped by the conflict policy have no target
+	if entry.Target == "" && opts.Operation != DeleteFiles {
+		return "", 0, "target exists", nil
+	}
+	if opts.Journal != nil && opts.Operation != ArchiveFiles {
+		if err := opts.Journal.recordDone(entry, hash); err != nil {
+			return "", 0, "", err
+		}
+	}
+	return entry.Target, entry.Size, "", nil
 }
 
 // checkFileAccess verifies if a file is accessible
@@ -166,21 +252,12 @@ func checkFileWritable(path string) error {
 	return nil
 }
 
-// Platform-specific constants for direct I/O
+// Buffer sizes for buffered copies
 const (
-	// Windows constants
-	fileNoBuffering = 0x20000000 // FILE_FLAG_NO_BUFFERING
-
-	// Default buffer size for aligned I/O
-	directIOAlignment = 4096
 	minBufferSize     = 4096
 	defaultBufferSize = 128 * 1024  // 128KB default
 	maxBufferSize     = 1024 * 1024 // 1MB max
 	maxPoolSize       = 32          // Maximum number of buffers in the pool
-	
-	// Platform-specific O_DIRECT values
-	linuxODirect   = 0x4000   // Linux
-	darwinODirect  = 0x100000 // Darwin/macOS
 )
 
 // BufferPool represents a pool of byte buffers
@@ -233,55 +310,75 @@ func optimizeBufferSize(fileSize int64) int {
 	}
 }
 
-// TODO enableDirectIO attempts to enable direct I/O on supported platforms 
-func enableDirectIO(file *os.File) {
-	// Direct I/O is handled differently on each platform:
-	// - Windows: Must be set when opening the file (FILE_FLAG_NO_BUFFERING)
-	// - Linux/Darwin: Can be set after opening via fcntl
-	// Since we can't modify flags after opening on Windows,
-	// this function is effectively a no-op on Windows
-	if runtime.GOOS == "windows" {
-		return
+// copyFile copies a file with optimized buffering and returns the target
+// path, which is empty when the conflict policy skipped the file
+func copyFile(src string, opts FileOperationOptions, srcInfo os.FileInfo) (string, error) {
+	if src == "" || srcInfo == nil {
+		return "", fmt.Errorf("invalid arguments")
 	}
 
-	// For non-Windows platforms, we'll use the file as is
-	// Direct I/O will be handled through the open flags
-	// This is a simplified approach that avoids platform-specific syscalls
-	return
-}
-
-// getDirectIOFlags returns platform-specific direct I/O flags
-func getDirectIOFlags() int {
-	switch runtime.GOOS {
-	case "windows":
-		return fileNoBuffering
-	case "linux":
-		return linuxODirect
-	case "darwin":
-		return darwinODirect
-	default:
-		return 0
+	target, err := prepareTarget(src, opts)
+	if err != nil || target == "" {
+		return "", err
+	}
+	defer releaseTarget(target)
+	if err := copyWithAttributes(src, target, srcInfo, opts); err != nil {
+		return "", err
 	}
+	return target, nil
 }
 
-// copyFile copies a file with optimized buffering
-func copyFile(src string, opts FileOperationOptions, srcInfo os.FileInfo) error {
-	if src == "" || srcInfo == nil {
-		return fmt.Errorf("invalid arguments")
+// copyWithAttributes copies src to target with the source attributes
+// selected by opts.PreserveAttributes
+func copyWithAttributes(src, target string, srcInfo os.FileInfo, opts FileOperationOptions) error {
+	var attrs *fileAttributes
+	if opts.PreserveAttributes != 0 {
+		var err error
+		if attrs, err = readAttributes(src, srcInfo, opts.PreserveAttributes); err != nil {
+			return err
+		}
 	}
 
-	targetPath := filepath.Join(opts.TargetDir, filepath.Base(src))
-	targetPath = resolveConflict(targetPath, opts.ConflictPolicy)
-	if targetPath == "" {
-		return nil
-	}
+	return copyFileTo(src, target, srcInfo, opts.Verify, opts.Limiter, attrs)
+}
+
+// copyFileTo copies src to targetPath through the temporary file
+// targetPath.tmp, see copyData. Copies of files larger than
+// checkpointInterval are resumable: they keep a checkpoint next to the
+// temporary file, which stays after a failure so the next copy to the same
+// target continues where this one stopped. With verify the source is hashed
+// while it is copied and the temporary file is read back and compared before
+// it is renamed into place. attrs, which may be nil, are applied to the
+// temporary file before the rename, so a target is only ever complete. The
+// copy waits for limiter, which may be nil.
+func copyFileTo(src, targetPath string, srcInfo os.FileInfo, verify bool, limiter *TransferLimiter, attrs *fileAttributes) error {
+	tmpPath := targetPath + ".tmp"
 
 	// Check file size
 	size := srcInfo.Size()
 	if size == 0 {
-		return copyEmptyFile(src, targetPath, srcInfo.Mode())
+		removePartial(tmpPath)
+		if err := copyEmptyFile(src, tmpPath, 0600); err != nil {
+			return err
+		}
+		if err := attrs.apply(tmpPath); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+		if err := setCopyMode(tmpPath, srcInfo, attrs); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+		if err := os.Rename(tmpPath, targetPath); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("rename failed: %v", err)
+		}
+		return nil
 	}
 
+	limiter.acquire()
+	defer limiter.release()
+
 	// Get buffer from pool
 	buf := copyBufferPool.Get()
 	defer copyBufferPool.Put(buf)
@@ -293,30 +390,52 @@ func copyFile(src string, opts FileOperationOptions, srcInfo os.FileInfo) error
 	}
 	defer srcFile.Close()
 
-	// Create temporary file
-	tmpPath := targetPath + ".tmp"
-	dstFile, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, srcInfo.Mode())
+	// Create the temporary file or continue an interrupted copy
+	dstFile, start, err := openPartial(tmpPath, src, srcInfo, srcFile)
 	if err != nil {
 		return fmt.Errorf("failed to create target: %v", err)
 	}
 
+	// Keep the partial file for the next attempt once it has a checkpoint
 	success := false
+	resumable := start > 0
 	defer func() {
-		if !success && tmpPath != "" {
-			os.Remove(tmpPath)
+		if !success && !resumable {
+			removePartial(tmpPath)
 		}
 	}()
 
-	// Copy data
-	written, err := io.CopyBuffer(dstFile, srcFile, buf)
-	if err != nil {
-		dstFile.Close()
-		return fmt.Errorf("copy failed: %v", err)
+	var srcHash hash.Hash64
+	if verify {
+		srcHash = xxhash.New()
+		if err := hashPrefix(srcHash, srcFile, start, buf); err != nil {
+			dstFile.Close()
+			return fmt.Errorf("copy failed: %v", err)
+		}
 	}
 
-	if written != size {
+	var checkpoint func(offset int64) error
+	if size > checkpointInterval {
+		checkpoint = func(offset int64) error {
+			if err := dstFile.Sync(); err != nil {
+				return fmt.Errorf("sync failed: %v", err)
+			}
+			if err := writeCheckpoint(tmpPath, copyCheckpoint{
+				Source:  src,
+				Size:    size,
+				ModTime: srcInfo.ModTime(),
+				Offset:  offset,
+			}); err != nil {
+				return err
+			}
+			resumable = true
+			return nil
+		}
+	}
+
+	if err := copyData(dstFile, srcFile, srcInfo, start, buf, srcHash, limiter, checkpoint); err != nil {
 		dstFile.Close()
-		return fmt.Errorf("size mismatch: expected %d, got %d", size, written)
+		return err
 	}
 
 	if err := dstFile.Sync(); err != nil {
@@ -328,15 +447,186 @@ func copyFile(src string, opts FileOperationOptions, srcInfo os.FileInfo) error
 		return fmt.Errorf("close failed: %v", err)
 	}
 
+	if verify {
+		if err := verifyCopy(tmpPath, srcHash.Sum64(), buf); err != nil {
+			resumable = false // A corrupt copy must not be continued
+			return err
+		}
+	}
+
+	if err := attrs.apply(tmpPath); err != nil {
+		resumable = false
+		return err
+	}
+	if err := setCopyMode(tmpPath, srcInfo, attrs); err != nil {
+		resumable = false
+		return err
+	}
+
 	// Atomic rename
 	if err := os.Rename(tmpPath, targetPath); err != nil {
 		return fmt.Errorf("rename failed: %v", err)
 	}
+	removePartial(tmpPath)
 
 	success = true
 	return nil
 }
 
+// hashPrefix adds the first n bytes of f to h and leaves the offset of f at n
+func hashPrefix(h hash.Hash64, f *os.File, n int64, buf []byte) error {
+	if n == 0 {
+		return nil
+	}
+	if _, err := io.CopyBuffer(h, io.NewSectionReader(f, 0, n), buf); err != nil {
+		return err
+	}
+	_, err := f.Seek(n, io.SeekStart)
+	return err
+}
+
+// dataSegment is a region of a file that holds data
+type dataSegment struct {
+	offset int64
+	length int64
+}
+
+// copyData copies the content of src from offset start to dst, which holds
+// the bytes before start. On Linux the data is reflinked or copied in the
+// kernel when it does not have to pass through here. Copies that are hashed
+// into srcHash or paced by limiter, and whatever the kernel cannot copy, go
+// through buf. Only the data regions of sparse files are copied, and dst is
+// extended to the full size so the holes are kept. When checkpoint is set it
+// is called every checkpointInterval bytes with the offset copied so far.
+func copyData(dst, src *os.File, srcInfo os.FileInfo, start int64, buf []byte, srcHash hash.Hash64, limiter *TransferLimiter, checkpoint func(int64) error) error {
+	size := srcInfo.Size()
+	fast := srcHash == nil && !limiter.paced()
+	if start == 0 && fast && cloneFile(dst, src) {
+		return nil
+	}
+
+	segments, sparse := dataSegments(src, srcInfo)
+	if !sparse {
+		segments = []dataSegment{{offset: 0, length: size}}
+	}
+
+	pos := start
+	for _, seg := range segments {
+		end := seg.offset + seg.length
+		if end <= start {
+			continue
+		}
+		offset := seg.offset
+		if offset < start {
+			offset = start
+		}
+		if srcHash != nil {
+			hashZeros(srcHash, offset-pos, buf)
+		}
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("copy failed: %v", err)
+		}
+		if _, err := dst.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("copy failed: %v", err)
+		}
+
+		for offset < end {
+			n := end - offset
+			if checkpoint != nil && n > checkpointInterval {
+				n = checkpointInterval
+			}
+			written, err := copyRange(dst, src, n, fast, buf, srcHash, limiter)
+			if err != nil {
+				return fmt.Errorf("copy failed: %v", err)
+			}
+			if written != n {
+				return fmt.Errorf("size mismatch: expected %d, got %d", size, offset+written)
+			}
+			offset += n
+			if checkpoint != nil && offset < size {
+				if err := checkpoint(offset); err != nil {
+					return err
+				}
+			}
+		}
+		pos = end
+	}
+
+	if srcHash != nil {
+		hashZeros(srcHash, size-pos, buf)
+	}
+	if sparse {
+		if err := dst.Truncate(size); err != nil {
+			return fmt.Errorf("copy failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// copyRange copies n bytes from the current offset of src to the current
+// offset of dst, in the kernel when fast is set, and returns how many were
+// copied
+func copyRange(dst, src *os.File, n int64, fast bool, buf []byte, srcHash hash.Hash64, limiter *TransferLimiter) (int64, error) {
+	var written int64
+	if fast {
+		c, err := copyKernel(dst, src, n)
+		if err != nil {
+			return c, err
+		}
+		written = c
+	}
+	if written < n {
+		reader := io.LimitReader(limiter.reader(src), n-written)
+		if srcHash != nil {
+			reader = io.TeeReader(reader, srcHash)
+		}
+		c, err := io.CopyBuffer(dst, reader, buf)
+		written += c
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// hashZeros adds n zero bytes, the content of a hole, to h
+func hashZeros(h hash.Hash64, n int64, buf []byte) {
+	if n <= 0 {
+		return
+	}
+	clear(buf)
+	for n > 0 {
+		chunk := buf
+		if n < int64(len(chunk)) {
+			chunk = chunk[:n]
+		}
+		h.Write(chunk)
+		n -= int64(len(chunk))
+	}
+}
+
+// verifyCopy reads the copy back from disk and compares its hash with the
+// hash of the source content
+func verifyCopy(path string, want uint64, buf []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("verification failed: %v", err)
+	}
+	defer f.Close()
+
+	// Make sure the data comes from the disk, not from the page cache
+	dropCache(f)
+
+	h := xxhash.New()
+	if _, err := io.CopyBuffer(h, f, buf); err != nil {
+		return fmt.Errorf("verification failed: %v", err)
+	}
+	if got := h.Sum64(); got != want {
+		return fmt.Errorf("verification failed: source hash %016x, copy hash %016x", want, got)
+	}
+	return nil
+}
+
 // Helper functions for copyFile
 func copyEmptyFile(src, dst string, mode os.FileMode) error {
 	if src == "" || dst == "" {
@@ -351,48 +641,97 @@ func copyEmptyFile(src, dst string, mode os.FileMode) error {
 	return nil
 }
 
-func shouldUseDirectIO(fileSize int64) bool {
-	return fileSize > 100*1024*1024 // 100MB
-}
-
-// moveFile moves a file to the target directory
-func moveFile(src string, opts FileOperationOptions, srcInfo os.FileInfo) error {
-	targetPath := filepath.Join(opts.TargetDir, filepath.Base(src))
-	targetPath = resolveConflict(targetPath, opts.ConflictPolicy)
-	if targetPath == "" {
-		return nil // Skip if conflict resolution returned empty path
+// moveFile moves a file to the target directory and returns the target
+// path, which is empty when the conflict policy skipped the file
+func moveFile(src string, opts FileOperationOptions, srcInfo os.FileInfo) (string, error) {
+	targetPath, err := prepareTarget(src, opts)
+	if err != nil || targetPath == "" {
+		return "", err // Skip if conflict resolution returned empty path
 	}
+	defer releaseTarget(targetPath)
 
 	// Check if source file is writable before attempting to move
 	if err := checkFileWritable(src); err != nil {
-		return fmt.Errorf("source file is not writable: %v", err)
+		return "", fmt.Errorf("source file is not writable: %v", err)
 	}
 
 	// Try to move the file directly first
-	err := os.Rename(src, targetPath)
-	if err == nil {
-		return nil
+	if err := os.Rename(src, targetPath); err == nil {
+		return targetPath, nil
 	}
 
 	// If direct move fails, try copy and delete
-	if err := copyFile(src, opts, srcInfo); err != nil {
-		return fmt.Errorf("failed to copy file during move: %v", err)
+	if err := copyWithAttributes(src, targetPath, srcInfo, opts); err != nil {
+		return "", fmt.Errorf("failed to copy file during move: %v", err)
 	}
 
 	if err := os.Remove(src); err != nil {
-		return fmt.Errorf("failed to remove source file after copy: %v", err)
+		return "", fmt.Errorf("failed to remove source file after copy: %v", err)
 	}
 
-	return nil
+	return targetPath, nil
+}
+
+// targetClaims counts the targets of copies and moves in progress. Conflicts
+// are resolved against them as well as the file system, so concurrent
+// workers never pick the same target for different files.
+var targetClaims = struct {
+	sync.Mutex
+	paths map[string]int
+}{paths: make(map[string]int)}
+
+// prepareTarget resolves and claims the target path of src and creates its
+// directory. It returns an empty path when the conflict policy skips the
+// file; otherwise the caller releases the target with releaseTarget.
+func prepareTarget(src string, opts FileOperationOptions) (string, error) {
+	targetClaims.Lock()
+	target := resolveConflictWith(targetPath(src, opts), opts.ConflictPolicy, func(path string) bool {
+		return targetClaims.paths[path] > 0 || pathExists(path)
+	})
+	if target != "" {
+		targetClaims.paths[target]++
+	}
+	targetClaims.Unlock()
+	if target == "" {
+		return "", nil
+	}
+
+	if opts.PreserveStructure {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			releaseTarget(target)
+			return "", fmt.Errorf("failed to create target directory: %v", err)
+		}
+	}
+	return target, nil
+}
+
+// releaseTarget drops a claim taken by prepareTarget
+func releaseTarget(target string) {
+	targetClaims.Lock()
+	defer targetClaims.Unlock()
+	if targetClaims.paths[target]--; targetClaims.paths[target] <= 0 {
+		delete(targetClaims.paths, target)
+	}
 }
 
 // resolveConflict handles file name conflicts according to the policy
 func resolveConflict(path string, policy ConflictResolutionPolicy) string {
+	return resolveConflictWith(path, policy, pathExists)
+}
+
+// pathExists reports whether a file exists at path
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// resolveConflictWith resolves conflicts against the paths reported by exists
+func resolveConflictWith(path string, policy ConflictResolutionPolicy, exists func(string) bool) string {
 	if policy == Overwrite {
 		return path
 	}
 
-	if _, err := os.Stat(path); err == nil {
+	if exists(path) {
 		switch policy {
 		case Skip:
 			return ""
@@ -404,7 +743,7 @@ func resolveConflict(path string, policy ConflictResolutionPolicy) string {
 			// Try timestamp-based names first
 			for i := 1; i <= 100; i++ {
 				newPath := fmt.Sprintf("%s_%d_%d%s", base, timestamp, i, ext)
-				if _, err := os.Stat(newPath); os.IsNotExist(err) {
+				if !exists(newPath) {
 					return newPath
 				}
 			}
@@ -422,29 +761,31 @@ func resolveConflict(path string, policy ConflictResolutionPolicy) string {
 	return path
 }
 
-// FileOperationProcessor handles file operations asynchronously
+// FileOperationProcessor handles file operations asynchronously. Add blocks
+// while the queue is full, so producers slow down to the pace of the workers
+// instead of losing operations. Every queued operation is reported exactly
+// once through OnResult, including those cancelled before they started.
 type FileOperationProcessor struct {
 	opChan   chan fileOperation
 	workers  int
 	wg       sync.WaitGroup
+	senders  sync.WaitGroup // Add calls that may still send on opChan
 	stopChan chan struct{}
 	started  bool
 	stopped  bool
 	mu       sync.Mutex
 	ctx      context.Context
 	cancel   context.CancelFunc
-	
-	// New fields for load control
-	maxQueueSize   int
-	currentWorkers int32
-	maxWorkers     int32
-	throttle       *time.Ticker
+
+	ops *rateLimiter
+
+	onResult func(OperationResult)
+	summary  OperationSummary
 }
 
 type fileOperation struct {
 	path string
 	opts FileOperationOptions
-	info os.FileInfo
 }
 
 // NewFileOperationProcessor creates a new processor with specified options
@@ -455,45 +796,64 @@ func NewFileOperationProcessor(opts ProcessorOptions) *FileOperationProcessor {
 	if opts.MaxQueueSize <= 0 {
 		opts.MaxQueueSize = 1000
 	}
-	if opts.ThrottleInterval <= 0 {
-		opts.ThrottleInterval = 100 * time.Millisecond
+	if opts.Context == nil {
+		opts.Context = context.Background()
 	}
-	
-	ctx, cancel := context.WithCancel(context.Background())
+
+	ctx, cancel := context.WithCancel(opts.Context)
 	return &FileOperationProcessor{
-		opChan:         make(chan fileOperation, opts.MaxQueueSize),
-		workers:        opts.Workers,
-		maxWorkers:     int32(opts.Workers * 2), // Allow doubling the number of workers if needed
-		stopChan:       make(chan struct{}),
-		ctx:           ctx,
-		cancel:        cancel,
-		throttle:      time.NewTicker(opts.ThrottleInterval),
-		maxQueueSize:  opts.MaxQueueSize,
+		opChan:   make(chan fileOperation, opts.MaxQueueSize),
+		workers:  opts.Workers,
+		stopChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		ops:      newRateLimiter(opts.MaxOpsPerSecond),
+		onResult: opts.OnResult,
 	}
 }
 
+// Summary returns the totals of all results reported so far
+func (p *FileOperationProcessor) Summary() *OperationSummary {
+	return &p.summary
+}
+
+// report counts a result and passes it to the OnResult callback
+func (p *FileOperationProcessor) report(r OperationResult) {
+	p.summary.Add(r)
+	if p.onResult != nil {
+		p.onResult(r)
+	}
+}
+
+// reject reports an operation that could not be queued
+func (p *FileOperationProcessor) reject(path string, opts FileOperationOptions, err error) error {
+	p.report(OperationResult{Path: path, Operation: opts.Operation, Status: OpFailed, Err: err})
+	return err
+}
+
 // Start begins processing file operations
 func (p *FileOperationProcessor) Start() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	
+
 	if p.started {
 		return fmt.Errorf("processor already started")
 	}
 	if p.stopped {
 		return fmt.Errorf("processor has been stopped")
 	}
-	
+
 	p.started = true
 	for i := 0; i < p.workers; i++ {
 		p.wg.Add(1)
 		go p.worker()
 	}
-	
+
 	return nil
 }
 
-// Stop gracefully stops all workers
+// Stop stops accepting operations and waits until every queued operation
+// has been processed, or reported as cancelled when the context is done
 func (p *FileOperationProcessor) Stop() {
 	p.mu.Lock()
 	if p.stopped {
@@ -501,139 +861,104 @@ func (p *FileOperationProcessor) Stop() {
 		return
 	}
 	p.stopped = true
-	p.cancel()
 	close(p.stopChan)
 	p.mu.Unlock()
-	
-	p.wg.Wait()
-	
-	p.mu.Lock()
-	if p.opChan != nil {
-		close(p.opChan)
-		p.opChan = nil
+
+	// Blocked Add calls give up on stopChan; once they are gone the queue
+	// can be closed and the workers drain it
+	p.senders.Wait()
+	close(p.opChan)
+	if p.started {
+		p.wg.Wait()
+	} else {
+		p.drain()
 	}
-	p.mu.Unlock()
+	p.cancel()
 }
 
-// Add queues a file operation for processing with backpressure
+// Add queues a file operation, waiting while the queue is full
 func (p *FileOperationProcessor) Add(path string, opts FileOperationOptions, info os.FileInfo) error {
+	return p.AddContext(context.Background(), path, opts, info)
+}
+
+// AddContext queues a file operation, waiting while the queue is full until
+// ctx is done. info may be nil, in which case the file is looked up so that
+// a missing file is rejected right away.
+// Operations that cannot be queued are reported as failed.
+func (p *FileOperationProcessor) AddContext(ctx context.Context, path string, opts FileOperationOptions, info os.FileInfo) error {
 	if p == nil {
 		return fmt.Errorf("processor is nil")
 	}
-	
-	if path == "" || info == nil {
-		return fmt.Errorf("invalid arguments: path or file info is nil")
+
+	if path == "" {
+		return fmt.Errorf("invalid arguments: path is empty")
+	}
+	// Archive entries are reported as skipped by the worker
+	if info == nil && !isArchiveEntry(path) {
+		if _, err := os.Stat(path); err != nil {
+			return p.reject(path, opts, err)
+		}
 	}
 
 	p.mu.Lock()
-	if p.stopped || p.opChan == nil {
+	if p.stopped {
 		p.mu.Unlock()
-		return fmt.Errorf("processor is stopped")
+		return p.reject(path, opts, fmt.Errorf("processor is stopped"))
 	}
+	p.senders.Add(1)
 	p.mu.Unlock()
-
-	// Apply throttling
-	<-p.throttle.C
+	defer p.senders.Done()
 
 	select {
-	case p.opChan <- fileOperation{path, opts, info}:
-		
+	case p.opChan <- fileOperation{path, opts}:
 		return nil
 	case <-p.stopChan:
-		return fmt.Errorf("processor is stopping")
+		return p.reject(path, opts, fmt.Errorf("processor is stopping"))
 	case <-p.ctx.Done():
-		return fmt.Errorf("processor context cancelled")
-	default:
-		// Try to adaptively increase number of workers
-		if atomic.LoadInt32(&p.currentWorkers) < p.maxWorkers {
-			p.mu.Lock()
-			if !p.stopped {
-				p.wg.Add(1)
-				atomic.AddInt32(&p.currentWorkers, 1)
-				go p.worker()
-			}
-			p.mu.Unlock()
-		}
-		return fmt.Errorf("operation queue is full")
+		return p.reject(path, opts, fmt.Errorf("processor context cancelled"))
+	case <-ctx.Done():
+		return p.reject(path, opts, ctx.Err())
 	}
 }
 
-// worker processes file operations from the queue
+// worker processes file operations from the queue until it is closed
 func (p *FileOperationProcessor) worker() {
 	defer p.wg.Done()
-	
-	for {
-		select {
-		case op, ok := <-p.opChan:
-			if !ok {
-				return
-			}
-			// Create timeout context for each operation
-			ctx, cancel := context.WithTimeout(p.ctx, 30*time.Minute)
-			err := p.processOperation(ctx, op)
-			cancel()
-			if err != nil {
-				logError("Failed to process file operation for %s: %v", op.path, err)
-			}
-		case <-p.stopChan:
-			return
-		case <-p.ctx.Done():
-			return
+
+	for op := range p.opChan {
+		result := p.processOperation(op)
+		if result.Err != nil {
+			logError("Failed to process file operation for %s: %v", op.path, result.Err)
 		}
+		p.report(result)
 	}
 }
 
-func (p *FileOperationProcessor) processOperation(ctx context.Context, op fileOperation) error {
-	done := make(chan error, 1)
-	go func() {
-		done <- HandleFileOperation(op.path, op.opts)
-	}()
-
-	select {
-	case err := <-done:
-		return err
-	case <-ctx.Done():
-		return fmt.Errorf("operation timed out or cancelled")
+// drain reports the operations left in a closed queue as cancelled
+func (p *FileOperationProcessor) drain() {
+	for op := range p.opChan {
+		p.report(cancelledResult(op))
 	}
 }
 
-// checkDiskSpace verifies if there's enough space for the file with timeout
-func checkDiskSpace(path string, size int64) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	done := make(chan error, 1)
-	go func() {
-		dir := filepath.Dir(path)
-		tmpPath := filepath.Join(dir, fmt.Sprintf(".space_check_%d", time.Now().UnixNano()))
-		
-		f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
-		if err != nil {
-			done <- fmt.Errorf("failed to create test file: %v", err)
-			return
-		}
-		defer func() {
-			f.Close()
-			os.Remove(tmpPath)
-		}()
-
-		// Check minimum size or 1MB, whichever is smaller
-		checkSize := int64(1024 * 1024)
-		if size < checkSize {
-			checkSize = size
-		}
-
-		if err := f.Truncate(checkSize); err != nil {
-			done <- fmt.Errorf("insufficient disk space: %v", err)
-			return
-		}
-		done <- nil
-	}()
+// processOperation waits for the rate limit and runs op. Once started an
+// operation runs to completion so no file is left half copied or moved.
+func (p *FileOperationProcessor) processOperation(op fileOperation) OperationResult {
+	if err := p.ops.wait(p.ctx, 1); err != nil {
+		return cancelledResult(op)
+	}
+	if p.ctx.Err() != nil {
+		return cancelledResult(op)
+	}
+	return RunFileOperation(op.path, op.opts)
+}
 
-	select {
-	case err := <-done:
-		return err
-	case <-ctx.Done():
-		return fmt.Errorf("disk space check timed out")
+// cancelledResult reports an operation that was queued but never started
+func cancelledResult(op fileOperation) OperationResult {
+	return OperationResult{
+		Path:      op.path,
+		Operation: op.opts.Operation,
+		Status:    OpFailed,
+		Err:       fmt.Errorf("operation cancelled"),
 	}
-} 
\ No newline at end of file
+}
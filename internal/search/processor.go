@@ -12,14 +12,21 @@ type resultProcessor struct {
 	results  chan<- SearchResult
 	seen     map[uint64]bool
 	dedupe   bool
+	progress *SearchProgress
+	summary  *SummaryCollector
 	mu       sync.Mutex
 }
 
+// quickHashSize is the number of leading bytes read for a quick hash
+const quickHashSize = 1024
+
 func newResultProcessor(results chan<- SearchResult, opts SearchOptions) *resultProcessor {
 	return &resultProcessor{
 		results: results,
 		seen:    make(map[uint64]bool),
-		dedupe:  opts.DeduplicateFiles,
+		dedupe:   opts.DeduplicateFiles,
+		progress: opts.Progress,
+		summary:  opts.Summary,
 	}
 }
 
@@ -33,9 +40,37 @@ func (rp *resultProcessor) add(result SearchResult) {
 		rp.seen[result.Hash] = true
 		rp.mu.Unlock()
 	}
+	rp.progress.matchedFile()
+	rp.summary.add(result)
 	rp.results <- result
 }
 
+// addError reports a path that could not be read as a *WalkError.
+// Errors bypass deduplication.
+func (rp *resultProcessor) addError(op, path string, err error, stopChan chan struct{}) {
+	rp.progress.failed()
+	select {
+	case rp.results <- SearchResult{Path: path, Error: newWalkError(op, path, err)}:
+	case <-stopChan:
+	}
+}
+
+// addEmptyDir reports an empty directory tree. Directories bypass
+// deduplication and the summary, which only count files.
+func (rp *resultProcessor) addEmptyDir(dir string, stopChan chan struct{}) {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		// Removed while walking
+		return
+	}
+	uid, gid, _ := fileOwner(info)
+	rp.progress.matchedFile()
+	select {
+	case rp.results <- SearchResult{Path: dir, Mode: info.Mode(), ModTime: info.ModTime(), UID: uid, GID: gid}:
+	case <-stopChan:
+	}
+}
+
 func (rp *resultProcessor) close() {
 	close(rp.results)
 }
@@ -52,7 +87,7 @@ func calculateQuickHash(path string, info os.FileInfo, buf []byte) uint64 {
 	// Hash first few bytes of the file
 	if f, err := os.Open(path); err == nil {
 		defer f.Close()
-		if n, err := f.Read(buf[:1024]); err == nil {
+		if n, err := f.Read(buf[:quickHashSize]); err == nil {
 			h.Write(buf[:n])
 		}
 	}
@@ -0,0 +1,151 @@
+package search
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SearchProgress collects live counters of a running search.
+// Set it as SearchOptions.Progress and poll Snapshot while results arrive.
+type SearchProgress struct {
+	// Counters are updated atomically and kept first for 64-bit alignment
+	dirsFound     int64
+	dirsVisited   int64
+	filesExamined int64
+	filesMatched  int64
+	bytesHashed   int64
+	errors        int64
+	done          int32
+
+	mu         sync.Mutex
+	start      time.Time
+	end        time.Time
+	currentDir string
+	last       ProgressSnapshot // Previous snapshot for throughput
+}
+
+// ProgressSnapshot is a point-in-time copy of search progress
+type ProgressSnapshot struct {
+	DirsFound      int64 // Directories discovered, including roots
+	DirsVisited    int64 // Directories read or skipped
+	FilesExamined  int64 // Files listed while walking directories
+	FilesMatched   int64 // Files sent as results
+	BytesHashed    int64 // Bytes read for quick hashes
+	Errors         int64 // Paths that could not be read
+	CurrentDir     string
+	Elapsed        time.Duration
+	FilesPerSecond float64 // Files examined per second since the previous snapshot
+	Done           bool
+}
+
+// NewSearchProgress creates an empty progress tracker
+func NewSearchProgress() *SearchProgress {
+	return &SearchProgress{}
+}
+
+// ETA estimates the remaining time from the share of discovered directories
+// that have not been visited yet. It grows as new directories are found.
+func (s ProgressSnapshot) ETA() time.Duration {
+	if s.Done || s.DirsVisited == 0 || s.DirsFound <= s.DirsVisited {
+		return 0
+	}
+	pending := s.DirsFound - s.DirsVisited
+	return time.Duration(float64(s.Elapsed) * float64(pending) / float64(s.DirsVisited))
+}
+
+// Snapshot returns the current counters and throughput
+func (p *SearchProgress) Snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	end := time.Now()
+	if !p.end.IsZero() {
+		end = p.end
+	}
+
+	snap := ProgressSnapshot{
+		DirsFound:     atomic.LoadInt64(&p.dirsFound),
+		DirsVisited:   atomic.LoadInt64(&p.dirsVisited),
+		FilesExamined: atomic.LoadInt64(&p.filesExamined),
+		FilesMatched:  atomic.LoadInt64(&p.filesMatched),
+		BytesHashed:   atomic.LoadInt64(&p.bytesHashed),
+		Errors:        atomic.LoadInt64(&p.errors),
+		CurrentDir:    p.currentDir,
+		Done:          atomic.LoadInt32(&p.done) == 1,
+	}
+	if !p.start.IsZero() {
+		snap.Elapsed = end.Sub(p.start)
+	}
+
+	// Throughput over the interval since the previous snapshot, or the
+	// whole search once it has finished
+	prev := p.last
+	if snap.Done {
+		prev = ProgressSnapshot{}
+	}
+	if interval := (snap.Elapsed - prev.Elapsed).Seconds(); interval > 0 {
+		snap.FilesPerSecond = float64(snap.FilesExamined-prev.FilesExamined) / interval
+	}
+	p.last = snap
+
+	return snap
+}
+
+// begin resets the counters at the start of a search
+func (p *SearchProgress) begin(roots int) {
+	p.mu.Lock()
+	p.start = time.Now()
+	p.end = time.Time{}
+	p.currentDir = ""
+	p.last = ProgressSnapshot{}
+	p.mu.Unlock()
+
+	atomic.StoreInt64(&p.dirsFound, int64(roots))
+	atomic.StoreInt64(&p.dirsVisited, 0)
+	atomic.StoreInt64(&p.filesExamined, 0)
+	atomic.StoreInt64(&p.filesMatched, 0)
+	atomic.StoreInt64(&p.bytesHashed, 0)
+	atomic.StoreInt64(&p.errors, 0)
+	atomic.StoreInt32(&p.done, 0)
+}
+
+// finish marks the search as complete and logs its metrics
+func (p *SearchProgress) finish() {
+	p.mu.Lock()
+	p.end = time.Now()
+	p.currentDir = ""
+	p.mu.Unlock()
+	atomic.StoreInt32(&p.done, 1)
+
+	s := p.Snapshot()
+	logInfo("Search finished in %v: %d dirs, %d files examined, %d matched, %d bytes hashed, %d errors (%.0f files/s)",
+		s.Elapsed, s.DirsVisited, s.FilesExamined, s.FilesMatched, s.BytesHashed, s.Errors, s.FilesPerSecond)
+}
+
+func (p *SearchProgress) visitDir(dir string) {
+	atomic.AddInt64(&p.dirsVisited, 1)
+	p.mu.Lock()
+	p.currentDir = dir
+	p.mu.Unlock()
+}
+
+func (p *SearchProgress) foundDirs(n int) {
+	atomic.AddInt64(&p.dirsFound, int64(n))
+}
+
+func (p *SearchProgress) examinedFiles(n int) {
+	atomic.AddInt64(&p.filesExamined, int64(n))
+}
+
+func (p *SearchProgress) matchedFile() {
+	atomic.AddInt64(&p.filesMatched, 1)
+}
+
+func (p *SearchProgress) hashedBytes(n int64) {
+	atomic.AddInt64(&p.bytesHashed, n)
+}
+
+func (p *SearchProgress) failed() {
+	atomic.AddInt64(&p.errors, 1)
+}
@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that refills at rate tokens per second and
+// holds at most one second's worth. Requests larger than the bucket are let
+// through by going into debt, so later callers wait for it to be paid off.
+// A nil limiter never waits. It is safe for concurrent use.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter for rate tokens per second, or nil when
+// rate is not positive
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// wait takes n tokens, sleeping until the bucket is back out of debt or ctx
+// is done
+func (l *rateLimiter) wait(ctx context.Context, n int64) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TransferLimits caps the load that copies and moves put on the disks
+type TransferLimits struct {
+	MaxBytesPerSecond  int64 // Combined copy rate of all files sharing the limiter; 0 = unlimited
+	MaxConcurrentFiles int   // Files copied at the same time; 0 = unlimited
+}
+
+// TransferLimiter applies TransferLimits to every copy that shares it, so
+// bulk copies from a NAS or to a USB disk leave the machine usable. A nil
+// limiter does not limit anything. It is safe for concurrent use.
+type TransferLimiter struct {
+	bytes *rateLimiter
+	chunk int
+	files chan struct{}
+}
+
+// NewTransferLimiter creates a limiter for limits, or returns nil when
+// limits does not limit anything
+func NewTransferLimiter(limits TransferLimits) *TransferLimiter {
+	if limits.MaxBytesPerSecond <= 0 && limits.MaxConcurrentFiles <= 0 {
+		return nil
+	}
+	l := &TransferLimiter{bytes: newRateLimiter(limits.MaxBytesPerSecond)}
+	if l.bytes != nil {
+		// Read in slices of about 1/16 s so the rate stays smooth
+		l.chunk = int(limits.MaxBytesPerSecond / 16)
+		if l.chunk < 4*1024 {
+			l.chunk = 4 * 1024
+		}
+	}
+	if limits.MaxConcurrentFiles > 0 {
+		l.files = make(chan struct{}, limits.MaxConcurrentFiles)
+	}
+	return l
+}
+
+// acquire waits until fewer than MaxConcurrentFiles copies are running
+func (l *TransferLimiter) acquire() {
+	if l != nil && l.files != nil {
+		l.files <- struct{}{}
+	}
+}
+
+// release ends a copy started with acquire
+func (l *TransferLimiter) release() {
+	if l != nil && l.files != nil {
+		<-l.files
+	}
+}
+
+// paced reports whether reads are limited to MaxBytesPerSecond
+func (l *TransferLimiter) paced() bool {
+	return l != nil && l.bytes != nil
+}
+
+// reader paces reads from r to MaxBytesPerSecond
+func (l *TransferLimiter) reader(r io.Reader) io.Reader {
+	if !l.paced() {
+		return r
+	}
+	return &limitedReader{r: r, limiter: l}
+}
+
+// limitedReader takes tokens for every byte read
+type limitedReader struct {
+	r       io.Reader
+	limiter *TransferLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > lr.limiter.chunk {
+		p = p[:lr.limiter.chunk]
+	}
+	n, err := lr.r.Read(p)
+	lr.limiter.bytes.wait(context.Background(), int64(n))
+	return n, err
+}
@@ -0,0 +1,182 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// checkpointInterval is how much data a copy writes between checkpoints.
+	// Smaller files are not resumable and start over after a failure.
+	checkpointInterval = 64 * 1024 * 1024
+
+	// checkpointSuffix is appended to the temporary file of a copy to name
+	// its checkpoint
+	checkpointSuffix = ".ckpt"
+
+	// resumeCheckSize is how much of the end of a partial file is compared
+	// with the source before a copy continues
+	resumeCheckSize = 1024 * 1024
+)
+
+// copyCheckpoint is the sidecar of a partial copy. It records how far the
+// temporary file holds synced data of the source, and which version of the
+// source that was.
+type copyCheckpoint struct {
+	Source  string    `json:"source"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Offset  int64     `json:"offset"`
+}
+
+// matches reports whether the checkpoint was written for this source file
+func (c copyCheckpoint) matches(src string, info os.FileInfo) bool {
+	return c.Source == src && c.Size == info.Size() && c.ModTime.Equal(info.ModTime()) &&
+		c.Offset > 0 && c.Offset <= c.Size
+}
+
+// writeCheckpoint replaces the checkpoint of the temporary file tmpPath
+func writeCheckpoint(tmpPath string, c copyCheckpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	path := tmpPath + checkpointSuffix
+	if err := os.WriteFile(path+".new", data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := os.Rename(path+".new", path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return nil
+}
+
+// readCheckpoint reads the checkpoint of the temporary file tmpPath
+func readCheckpoint(tmpPath string) (copyCheckpoint, error) {
+	var c copyCheckpoint
+	data, err := os.ReadFile(tmpPath + checkpointSuffix)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// removePartial removes a temporary file and its checkpoint
+func removePartial(tmpPath string) {
+	os.Remove(tmpPath)
+	os.Remove(tmpPath + checkpointSuffix)
+	os.Remove(tmpPath + checkpointSuffix + ".new")
+}
+
+// openPartial opens the temporary file of a copy of src. A partial file left
+// by an interrupted copy of the same source is continued from its checkpoint
+// once its last bytes are found to match the source; anything else at
+// tmpPath is left over from a crashed run and replaced. It returns the
+// offset to continue from, with both files positioned there. The file is
+// created 0600 so that a partial copy of a read-only source can be reopened;
+// setCopyMode gives it its final mode.
+func openPartial(tmpPath, src string, srcInfo os.FileInfo, srcFile *os.File) (*os.File, int64, error) {
+	if c, err := readCheckpoint(tmpPath); err == nil && c.matches(src, srcInfo) {
+		if f, err := os.OpenFile(tmpPath, os.O_RDWR, 0); err == nil {
+			if partialIntact(f, srcFile, c.Offset) && f.Truncate(c.Offset) == nil {
+				if _, err := f.Seek(c.Offset, io.SeekStart); err == nil {
+					if _, err := srcFile.Seek(c.Offset, io.SeekStart); err == nil {
+						logInfo("Resuming copy of %s at %d of %d bytes", src, c.Offset, c.Size)
+						return f, c.Offset, nil
+					}
+				}
+			}
+			f.Close()
+		}
+	}
+
+	removePartial(tmpPath)
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	return f, 0, err
+}
+
+// setCopyMode gives the temporary file of a copy the mode of its source,
+// limited by the umask as for a newly created file. It is called after attrs
+// were applied and leaves a mode preserved by them alone.
+func setCopyMode(tmpPath string, srcInfo os.FileInfo, attrs *fileAttributes) error {
+	if attrs != nil && attrs.flags&PreserveMode != 0 {
+		return nil
+	}
+	if err := os.Chmod(tmpPath, srcInfo.Mode().Perm()&^umask); err != nil {
+		return fmt.Errorf("failed to set mode: %v", err)
+	}
+	return nil
+}
+
+// partialIntact reports whether the partial file holds at least offset
+// bytes and the last of them match the source
+func partialIntact(partial, src *os.File, offset int64) bool {
+	info, err := partial.Stat()
+	if err != nil || info.Size() < offset {
+		return false
+	}
+	n := int64(resumeCheckSize)
+	if offset < n {
+		n = offset
+	}
+	want := make([]byte, n)
+	got := make([]byte, n)
+	if _, err := src.ReadAt(want, offset-n); err != nil {
+		return false
+	}
+	if _, err := partial.ReadAt(got, offset-n); err != nil {
+		return false
+	}
+	return bytes.Equal(want, got)
+}
+
+// CleanPartialCopies removes the partial files of interrupted copies below
+// dir and returns how many were removed. A partial file with a checkpoint is
+// removed when the checkpoint is older than maxAge; one without, left by a
+// copy that crashed before its first checkpoint, when the file itself is.
+func CleanPartialCopies(dir string, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // Skip unreadable directories
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		tmpPath := path
+		switch {
+		case strings.HasSuffix(path, ".tmp"+checkpointSuffix):
+			tmpPath = strings.TrimSuffix(path, checkpointSuffix)
+		case strings.HasSuffix(path, ".tmp"):
+			// Judged by its checkpoint when it has one
+			if _, err := os.Lstat(path + checkpointSuffix); err == nil {
+				return nil
+			}
+		default:
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		removePartial(tmpPath)
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to clean partial copies: %v", err)
+	}
+	return removed, nil
+}
@@ -1,12 +1,12 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
 	"runtime/debug"
 	"sync"
-	"time"
 )
 
 // Search performs concurrent file search based on given options
@@ -30,6 +30,13 @@ func Search(opts SearchOptions) chan SearchResult {
 		DirStats: make(map[string]*DirStats),
 	}
 
+	if opts.Progress == nil {
+		opts.Progress = NewSearchProgress()
+	}
+	opts.Progress.begin(len(opts.RootDirs))
+	opts.Summary.begin(opts.RootDirs)
+	resolveOwnerFilters(&opts)
+
 	patterns := preparePatterns(opts)
 	
 	results := make(chan SearchResult, opts.BufferSize)
@@ -40,11 +47,23 @@ func Search(opts SearchOptions) chan SearchResult {
 	
 	// Create file operation processor if needed
 	var fileOpProcessor *FileOperationProcessor
+	cancelFileOps := func() {}
 	if opts.FileOp.Operation != NoOperation {
+		// Stopping the search cancels file operations that have not started
+		var ctx context.Context
+		ctx, cancelFileOps = context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-opts.StopChan:
+				cancelFileOps()
+			case <-ctx.Done():
+			}
+		}()
 		fileOpProcessor = NewFileOperationProcessor(ProcessorOptions{
-			Workers:          opts.MaxWorkers / 2,
-			MaxQueueSize:     1000,
-			ThrottleInterval: 100 * time.Millisecond,
+			Workers:      opts.MaxWorkers / 2,
+			MaxQueueSize: 1000,
+			Context:      ctx,
+			OnResult:     opts.OnFileOpResult,
 		})
 		fileOpProcessor.Start()
 	}
@@ -88,7 +107,7 @@ func Search(opts SearchOptions) chan SearchResult {
 	for _, rootDir := range opts.RootDirs {
 		go func(dir string) {
 			defer walkWg.Done()
-			walkDirectoryOptimized(dir, paths, opts)
+			walkDirectoryOptimized(dir, paths, opts, processor)
 		}(rootDir)
 	}
 	
@@ -98,11 +117,13 @@ func Search(opts SearchOptions) chan SearchResult {
 		close(paths)
 		wg.Wait()
 		
-		// Stop file operation processor if it was used
+		// Wait for the queued file operations to finish
 		if fileOpProcessor != nil {
 			fileOpProcessor.Stop()
 		}
+		cancelFileOps()
 		
+		opts.Progress.finish()
 		processor.close()
 	}()
 	
@@ -121,21 +142,48 @@ func processFileBatch(batch []string, patterns compiledPatterns, opts SearchOpti
 	for _, path := range batch {
 		info, err := os.Lstat(path)
 		if err != nil {
+			// Only report files the user would have seen in the results
+			if opts.ReportErrors && matchesPatterns(path, patterns, opts.IgnoreCase) {
+				processor.addError("lstat", path, err, opts.StopChan)
+			} else {
+				opts.Progress.failed()
+			}
 			continue
 		}
 
+		if opts.SearchArchives && info.Mode().IsRegular() {
+			if kind := archiveKindOf(path); kind != notArchive {
+				if err := scanArchive(path, kind, patterns, opts, processor); err != nil {
+					logError("Failed to read archive %s: %v", path, err)
+					if opts.ReportErrors {
+						processor.addError("archive", path, err, opts.StopChan)
+					} else {
+						opts.Progress.failed()
+					}
+				}
+			}
+		}
+
 		// Check if file matches patterns and constraints before processing
 		if !matchesPatterns(path, patterns, opts.IgnoreCase) ||
 			!matchesFileConstraints(info, opts) {
 			continue
 		}
+
+		if opts.onFile != nil {
+			opts.Progress.matchedFile()
+			opts.onFile(path, info)
+			continue
+		}
 		
 		// Use mmap for large files
 		if opts.UseMMap && info.Size() >= opts.MinMMapSize {
 			if err := processByMMap(path, info, patterns, opts, processor); err == nil {
 				// Queue file operation if needed
 				if fileOpProcessor != nil && opts.FileOp.Operation != NoOperation {
-					fileOpProcessor.Add(path, opts.FileOp, info)
+					if err := fileOpProcessor.Add(path, opts.FileOp, info); err != nil {
+						logError("Failed to queue file operation for %s: %v", path, err)
+					}
 				}
 				continue
 			}
@@ -155,13 +203,17 @@ func processFileBatch(batch []string, patterns compiledPatterns, opts SearchOpti
 			}()
 			hash = calculateQuickHash(path, info, buf)
 		}()
+		opts.Progress.hashedBytes(min(info.Size(), quickHashSize))
 		
+		uid, gid, _ := fileOwner(info)
 		result := SearchResult{
 			Path:    path,
 			Size:    info.Size(),
 			Mode:    info.Mode(),
 			ModTime: info.ModTime(),
 			Hash:    hash,
+			UID:     uid,
+			GID:     gid,
 			Error:   hashErr,
 		}
 		
@@ -169,7 +221,9 @@ func processFileBatch(batch []string, patterns compiledPatterns, opts SearchOpti
 		
 		// Queue file operation if needed
 		if fileOpProcessor != nil && opts.FileOp.Operation != NoOperation {
-			fileOpProcessor.Add(path, opts.FileOp, info)
+			if err := fileOpProcessor.Add(path, opts.FileOp, info); err != nil {
+				logError("Failed to queue file operation for %s: %v", path, err)
+			}
 		}
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd
+
+package search
+
+import "os"
+
+// dataSegments cannot find holes on this platform, so sparse files are
+// copied as a whole
+func dataSegments(f *os.File, info os.FileInfo) ([]dataSegment, bool) {
+	return nil, false
+}
@@ -0,0 +1,50 @@
+//go:build linux || darwin || freebsd
+
+package search
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// dataSegments returns the data regions of a sparse file using SEEK_DATA
+// and SEEK_HOLE. ok is false for files without holes and when the file
+// system cannot report them, in which case the file is copied as a whole.
+// The file offset is reset to the start.
+func dataSegments(f *os.File, info os.FileInfo) ([]dataSegment, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int64(stat.Blocks)*512 >= info.Size() {
+		return nil, false
+	}
+	defer f.Seek(0, io.SeekStart)
+
+	size := info.Size()
+	fd := int(f.Fd())
+	segments := make([]dataSegment, 0)
+	for offset := int64(0); offset < size; {
+		data, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break // Only a hole is left
+		}
+		if err != nil {
+			return nil, false
+		}
+		if data >= size {
+			break
+		}
+		hole, err := unix.Seek(fd, data, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, false
+		}
+		if hole > size {
+			hole = size
+		}
+		segments = append(segments, dataSegment{offset: data, length: hole - data})
+		offset = hole
+	}
+	return segments, true
+}
@@ -0,0 +1,227 @@
+package search
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSummaryTopN is the number of largest files kept when no limit is given
+const DefaultSummaryTopN = 10
+
+// sizeBucketBounds are the upper bounds of the size histogram buckets;
+// the last bucket has no upper bound
+var sizeBucketBounds = []int64{1 << 10, 64 << 10, 1 << 20, 16 << 20, 256 << 20, 1 << 30}
+
+// SearchSummary describes the files found by a completed search
+type SearchSummary struct {
+	Files         int
+	TotalSize     int64
+	Roots         map[string]*DirStats // Keyed by cleaned entry of RootDirs
+	TopDirs       map[string]*DirStats // Keyed by first-level subdirectory of a root
+	Extensions    []ExtStats           // Sorted by total size, largest first
+	SizeHistogram []SizeBucket
+	Largest       []SearchResult // Largest first
+	Oldest        SearchResult   // Least recently modified
+	Newest        SearchResult   // Most recently modified
+}
+
+// ExtStats holds totals for one file extension ("" for files without one)
+type ExtStats struct {
+	Ext       string
+	Files     int
+	TotalSize int64
+}
+
+// SizeBucket counts files with Min <= size < Max; Max is 0 for the last bucket
+type SizeBucket struct {
+	Min       int64
+	Max       int64
+	Files     int
+	TotalSize int64
+}
+
+// SummaryCollector builds a SearchSummary from results as they are found.
+// Set it as SearchOptions.Summary and call Summary after the result channel closes.
+type SummaryCollector struct {
+	mu      sync.Mutex
+	topN    int
+	roots   []string
+	summary SearchSummary
+	exts    map[string]*ExtStats
+}
+
+// NewSummaryCollector creates a collector keeping the topN largest files
+func NewSummaryCollector(topN int) *SummaryCollector {
+	if topN <= 0 {
+		topN = DefaultSummaryTopN
+	}
+	c := &SummaryCollector{topN: topN}
+	c.begin(nil)
+	return c
+}
+
+// Summary returns a copy of the totals collected so far
+func (c *SummaryCollector) Summary() SearchSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.summary
+	s.Roots = make(map[string]*DirStats, len(c.summary.Roots))
+	for k, v := range c.summary.Roots {
+		s.Roots[k] = v.clone()
+	}
+	s.TopDirs = make(map[string]*DirStats, len(c.summary.TopDirs))
+	for k, v := range c.summary.TopDirs {
+		s.TopDirs[k] = v.clone()
+	}
+	s.SizeHistogram = append([]SizeBucket(nil), c.summary.SizeHistogram...)
+	s.Largest = append([]SearchResult(nil), c.summary.Largest...)
+
+	s.Extensions = make([]ExtStats, 0, len(c.exts))
+	for _, ext := range c.exts {
+		s.Extensions = append(s.Extensions, *ext)
+	}
+	sort.Slice(s.Extensions, func(i, j int) bool {
+		if s.Extensions[i].TotalSize != s.Extensions[j].TotalSize {
+			return s.Extensions[i].TotalSize > s.Extensions[j].TotalSize
+		}
+		return s.Extensions[i].Ext < s.Extensions[j].Ext
+	})
+
+	return s
+}
+
+// begin resets the collector for a search over roots
+func (c *SummaryCollector) begin(roots []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.roots = cleanRoots(roots)
+
+	c.summary = SearchSummary{
+		Roots:         make(map[string]*DirStats),
+		TopDirs:       make(map[string]*DirStats),
+		SizeHistogram: make([]SizeBucket, len(sizeBucketBounds)+1),
+	}
+	var lower int64
+	for i, upper := range sizeBucketBounds {
+		c.summary.SizeHistogram[i] = SizeBucket{Min: lower, Max: upper}
+		lower = upper
+	}
+	c.summary.SizeHistogram[len(sizeBucketBounds)] = SizeBucket{Min: lower}
+	c.exts = make(map[string]*ExtStats)
+}
+
+// add records a found file
+func (c *SummaryCollector) add(result SearchResult) {
+	if c == nil || result.Error != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &c.summary
+	s.Files++
+	s.TotalSize += result.Size
+
+	if root, rel, ok := findRoot(result.Path, c.roots); ok {
+		top := root
+		if first := strings.SplitN(rel, string(filepath.Separator), 2); len(first) == 2 {
+			top = filepath.Join(root, first[0])
+		}
+		addDirStats(s.Roots, root, result)
+		addDirStats(s.TopDirs, top, result)
+	}
+
+	ext := strings.ToLower(filepath.Ext(result.Path))
+	e, ok := c.exts[ext]
+	if !ok {
+		e = &ExtStats{Ext: ext}
+		c.exts[ext] = e
+	}
+	e.Files++
+	e.TotalSize += result.Size
+
+	b := sort.Search(len(sizeBucketBounds), func(i int) bool { return result.Size < sizeBucketBounds[i] })
+	s.SizeHistogram[b].Files++
+	s.SizeHistogram[b].TotalSize += result.Size
+
+	if s.Files == 1 || result.ModTime.Before(s.Oldest.ModTime) {
+		s.Oldest = result
+	}
+	if s.Files == 1 || result.ModTime.After(s.Newest.ModTime) {
+		s.Newest = result
+	}
+
+	// Keep Largest sorted; it never holds more than topN entries
+	if len(s.Largest) < c.topN || result.Size > s.Largest[len(s.Largest)-1].Size {
+		i := sort.Search(len(s.Largest), func(i int) bool { return s.Largest[i].Size < result.Size })
+		s.Largest = append(s.Largest, SearchResult{})
+		copy(s.Largest[i+1:], s.Largest[i:])
+		s.Largest[i] = result
+		if len(s.Largest) > c.topN {
+			s.Largest = s.Largest[:c.topN]
+		}
+	}
+}
+
+// cleanRoots returns cleaned copies of roots, longest first so nested roots
+// win in findRoot
+func cleanRoots(roots []string) []string {
+	cleaned := make([]string, 0, len(roots))
+	for _, root := range roots {
+		cleaned = append(cleaned, filepath.Clean(root))
+	}
+	sort.Slice(cleaned, func(i, j int) bool { return len(cleaned[i]) > len(cleaned[j]) })
+	return cleaned
+}
+
+// findRoot returns the first of the cleaned roots containing path and the
+// path relative to it
+func findRoot(path string, roots []string) (root, rel string, ok bool) {
+	for _, r := range roots {
+		rel, err := filepath.Rel(r, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return r, rel, true
+	}
+	return "", "", false
+}
+
+// addDirStats adds result to the stats stored under key
+func addDirStats(stats map[string]*DirStats, key string, result SearchResult) {
+	s, ok := stats[key]
+	if !ok {
+		s = &DirStats{CommonExts: make(map[string]int)}
+		stats[key] = s
+	}
+	s.add(result.Path, result.Size, result.ModTime)
+}
+
+// add records a file in the directory statistics
+func (s *DirStats) add(path string, size int64, modTime time.Time) {
+	s.FileCount++
+	s.TotalSize += size
+	s.CommonExts[strings.ToLower(filepath.Ext(path))]++
+	if modTime.After(s.LastModified) {
+		s.LastModified = modTime
+	}
+	s.UpdateCount++
+}
+
+// clone returns a deep copy of the statistics
+func (s *DirStats) clone() *DirStats {
+	c := *s
+	c.CommonExts = make(map[string]int, len(s.CommonExts))
+	for ext, n := range s.CommonExts {
+		c.CommonExts[ext] = n
+	}
+	return &c
+}
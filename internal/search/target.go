@@ -0,0 +1,63 @@
+package search
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// flattenSeparator joins path elements in flattened file names
+const flattenSeparator = "__"
+
+// relativePath returns path relative to the deepest of roots that contains
+// it. With several roots the root's base name is kept in front so files from
+// different roots stay apart. ok is false for paths outside every root.
+func relativePath(path string, roots []string) (string, bool) {
+	root, rel, ok := findRoot(filepath.Clean(path), cleanRoots(roots))
+	if !ok {
+		return "", false
+	}
+	if len(roots) > 1 {
+		rel = filepath.Join(filepath.Base(root), rel)
+	}
+	return rel, true
+}
+
+// CommonDir returns the deepest directory containing all paths, for use as
+// FileOperationOptions.Roots when the search roots are not known
+func CommonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	common := filepath.Dir(filepath.Clean(paths[0]))
+	for _, p := range paths[1:] {
+		dir := filepath.Dir(filepath.Clean(p))
+		for {
+			if _, _, ok := findRoot(dir, []string{common}); ok {
+				break
+			}
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// targetPath returns where src is placed under opts.TargetDir. By default
+// only the base name is kept; PreserveStructure recreates the path relative
+// to opts.Roots and FlattenPaths encodes it into the file name instead.
+// Files outside every root fall back to their base name.
+func targetPath(src string, opts FileOperationOptions) string {
+	name := filepath.Base(src)
+	if opts.PreserveStructure || opts.FlattenPaths {
+		if rel, ok := relativePath(src, opts.Roots); ok {
+			name = rel
+			if !opts.PreserveStructure {
+				name = strings.ReplaceAll(rel, string(filepath.Separator), flattenSeparator)
+			}
+		}
+	}
+	return filepath.Join(opts.TargetDir, name)
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// TrashedFile records where a file was moved by TrashFile
+type TrashedFile struct {
+	OriginalPath string
+	TrashPath    string // The file inside the trash
+	InfoPath     string // The .trashinfo file; empty where the trash keeps no metadata
+	DeletionDate time.Time
+}
+
+// RestoreTrashed moves a trashed file back to its original path. Existing
+// files at that path are handled with policy; the restored path is returned.
+func RestoreTrashed(t TrashedFile, policy ConflictResolutionPolicy) (string, error) {
+	if _, err := os.Lstat(t.TrashPath); err != nil {
+		return "", fmt.Errorf("trashed file not found: %v", err)
+	}
+
+	target := resolveConflict(t.OriginalPath, policy)
+	if target == "" {
+		return "", fmt.Errorf("cannot restore %s: file exists", t.OriginalPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := os.Rename(t.TrashPath, target); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %v", t.OriginalPath, err)
+	}
+	if t.InfoPath != "" {
+		if err := os.Remove(t.InfoPath); err != nil {
+			logError("Failed to remove trash info %s: %v", t.InfoPath, err)
+		}
+	}
+	return target, nil
+}
+
+// uniqueTrashName returns name, or name with a numeric suffix, for which
+// reserve succeeds. reserve reports os.ErrExist for names already taken.
+func uniqueTrashName(name string, reserve func(string) error) (string, error) {
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	candidate := name
+	for i := 2; i < 10000; i++ {
+		err := reserve(candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%d%s", stem, i, ext)
+	}
+	return "", fmt.Errorf("no free name in trash for %s", name)
+}
+
+// sortTrashed orders trashed files by deletion date, oldest first
+func sortTrashed(files []TrashedFile) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].DeletionDate.Before(files[j].DeletionDate)
+	})
+}
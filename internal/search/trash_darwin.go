@@ -0,0 +1,44 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TrashSupported reports whether TrashFile works on this platform
+const TrashSupported = true
+
+// TrashFile moves path to ~/.Trash. The Finder keeps no metadata the
+// program can write, so only the returned record allows restoring it.
+func TrashFile(path string) (TrashedFile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return TrashedFile{}, err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return TrashedFile{}, fmt.Errorf("cannot find home directory: %v", err)
+	}
+	trashDir := filepath.Join(home, ".Trash")
+
+	var trashPath string
+	_, err = uniqueTrashName(filepath.Base(abs), func(name string) error {
+		trashPath = filepath.Join(trashDir, name)
+		if _, err := os.Lstat(trashPath); err == nil {
+			return os.ErrExist
+		}
+		return os.Rename(abs, trashPath)
+	})
+	if err != nil {
+		return TrashedFile{}, fmt.Errorf("failed to move to trash: %v", err)
+	}
+	return TrashedFile{OriginalPath: abs, TrashPath: trashPath, DeletionDate: time.Now()}, nil
+}
+
+// ListTrash is not supported because the macOS trash keeps no original paths
+// that can be read
+func ListTrash() ([]TrashedFile, error) {
+	return nil, fmt.Errorf("listing the trash is not supported on macOS")
+}
@@ -0,0 +1,313 @@
+//go:build unix && !darwin
+
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// trashDateFormat is the DeletionDate format of the freedesktop.org Trash
+// specification, in local time
+const trashDateFormat = "2006-01-02T15:04:05"
+
+// TrashSupported reports whether TrashFile works on this platform
+const TrashSupported = true
+
+// TrashFile moves path to the trash following the freedesktop.org Trash
+// specification: the home trash when the file is on the same file system,
+// otherwise the trash at the top of the file system the file lives on
+func TrashFile(path string) (TrashedFile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return TrashedFile{}, err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return TrashedFile{}, fmt.Errorf("failed to get file info: %v", err)
+	}
+
+	trashDir, topDir, err := trashDirFor(abs, info)
+	if err != nil {
+		return TrashedFile{}, err
+	}
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return TrashedFile{}, fmt.Errorf("failed to create trash: %v", err)
+		}
+	}
+
+	// Paths in a top directory trash are relative to the top directory
+	recorded := abs
+	if topDir != "" {
+		if recorded, err = filepath.Rel(topDir, abs); err != nil {
+			return TrashedFile{}, err
+		}
+	}
+	now := time.Now()
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: recorded}).EscapedPath(), now.Format(trashDateFormat))
+
+	// Creating the info file with O_EXCL reserves the name
+	name, err := uniqueTrashName(filepath.Base(abs), func(name string) error {
+		if _, err := os.Lstat(filepath.Join(filesDir, name)); err == nil {
+			return os.ErrExist
+		}
+		f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+	if err != nil {
+		return TrashedFile{}, fmt.Errorf("failed to write trash info: %v", err)
+	}
+
+	trashed := TrashedFile{
+		OriginalPath: abs,
+		TrashPath:    filepath.Join(filesDir, name),
+		InfoPath:     filepath.Join(infoDir, name+".trashinfo"),
+		DeletionDate: now,
+	}
+	if err := os.Rename(abs, trashed.TrashPath); err != nil {
+		os.Remove(trashed.InfoPath)
+		return TrashedFile{}, fmt.Errorf("failed to move to trash: %v", err)
+	}
+	return trashed, nil
+}
+
+// ListTrash returns the files in the home trash and in the trash
+// directories at the top of every mounted file system, oldest first. Mounts
+// are read from /proc/self/mounts; where that is missing only the home trash
+// is listed.
+func ListTrash() ([]TrashedFile, error) {
+	home, err := homeTrashDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := listTrashDir(home, "")
+	if err != nil {
+		return nil, err
+	}
+
+	uid := strconv.Itoa(os.Getuid())
+	for _, top := range mountPoints() {
+		dirs := []string{filepath.Join(top, ".Trash-"+uid)}
+		// Like trashDirFor, only trust a shared .Trash with the sticky bit
+		shared := filepath.Join(top, ".Trash")
+		if st, err := os.Lstat(shared); err == nil && st.IsDir() && st.Mode()&os.ModeSticky != 0 {
+			dirs = append(dirs, filepath.Join(shared, uid))
+		}
+		for _, dir := range dirs {
+			if dir == home {
+				continue
+			}
+			more, err := listTrashDir(dir, top)
+			if err != nil {
+				logError("Skipping trash %s: %v", dir, err)
+				continue
+			}
+			files = append(files, more...)
+		}
+	}
+	sortTrashed(files)
+	return files, nil
+}
+
+// listTrashDir returns the files in one trash directory. Paths in the trash
+// of a top directory are relative to topDir.
+func listTrashDir(trashDir, topDir string) ([]TrashedFile, error) {
+	infoDir := filepath.Join(trashDir, "info")
+	entries, err := os.ReadDir(infoDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash: %v", err)
+	}
+
+	files := make([]TrashedFile, 0, len(entries))
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".trashinfo")
+		if !ok {
+			continue
+		}
+		t, err := readTrashInfo(filepath.Join(infoDir, e.Name()))
+		if err != nil {
+			logError("Skipping trash entry %s: %v", e.Name(), err)
+			continue
+		}
+		if topDir != "" && !filepath.IsAbs(t.OriginalPath) {
+			t.OriginalPath = filepath.Join(topDir, t.OriginalPath)
+		}
+		t.TrashPath = filepath.Join(trashDir, "files", name)
+		files = append(files, t)
+	}
+	return files, nil
+}
+
+// pseudoFileSystems never hold a trash and are skipped when listing mounts
+var pseudoFileSystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "devtmpfs": true, "fusectl": true,
+	"hugetlbfs": true, "mqueue": true, "nsfs": true, "proc": true, "pstore": true,
+	"securityfs": true, "sysfs": true, "tracefs": true,
+}
+
+// mountPoints returns the mount points listed in /proc/self/mounts, without
+// pseudo file systems
+func mountPoints() []string {
+	data, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var mounts []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || pseudoFileSystems[fields[2]] {
+			continue
+		}
+		// Spaces and other special characters are escaped as octal
+		dir := unescapeMountPath(fields[1])
+		if !seen[dir] {
+			seen[dir] = true
+			mounts = append(mounts, dir)
+		}
+	}
+	return mounts
+}
+
+// unescapeMountPath decodes the \ooo octal escapes of /proc/self/mounts
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// readTrashInfo parses a .trashinfo file
+func readTrashInfo(infoPath string) (TrashedFile, error) {
+	f, err := os.Open(infoPath)
+	if err != nil {
+		return TrashedFile{}, err
+	}
+	defer f.Close()
+
+	t := TrashedFile{InfoPath: infoPath}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if t.OriginalPath, err = url.PathUnescape(value); err != nil {
+				return t, fmt.Errorf("invalid path: %v", err)
+			}
+		case "DeletionDate":
+			t.DeletionDate, _ = time.ParseInLocation(trashDateFormat, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return t, err
+	}
+	if t.OriginalPath == "" {
+		return t, fmt.Errorf("missing Path")
+	}
+	return t, nil
+}
+
+// homeTrashDir returns $XDG_DATA_HOME/Trash
+func homeTrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot find home directory: %v", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// trashDirFor picks the trash for a file. topDir is set when the trash is
+// not the home trash.
+func trashDirFor(path string, info os.FileInfo) (trashDir, topDir string, err error) {
+	home, err := homeTrashDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create trash: %v", err)
+	}
+	dev, ok := deviceOf(info)
+	if homeInfo, err := os.Stat(home); err == nil {
+		if homeDev, _ := deviceOf(homeInfo); !ok || homeDev == dev {
+			return home, "", nil
+		}
+	}
+
+	topDir = mountPoint(filepath.Dir(path), dev)
+	uid := strconv.Itoa(os.Getuid())
+
+	// Prefer an administrator-created $topdir/.Trash with the sticky bit
+	shared := filepath.Join(topDir, ".Trash")
+	if st, err := os.Lstat(shared); err == nil && st.IsDir() && st.Mode()&os.ModeSticky != 0 {
+		return filepath.Join(shared, uid), topDir, nil
+	}
+	return filepath.Join(topDir, ".Trash-"+uid), topDir, nil
+}
+
+// mountPoint returns the topmost ancestor of dir on the device dev
+func mountPoint(dir string, dev uint64) string {
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		info, err := os.Stat(parent)
+		if err != nil {
+			return dir
+		}
+		if d, ok := deviceOf(info); !ok || d != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// deviceOf returns the device a file lives on
+func deviceOf(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
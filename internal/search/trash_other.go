@@ -0,0 +1,19 @@
+//go:build !unix
+
+package search
+
+import "fmt"
+
+// TrashSupported reports whether TrashFile works on this platform. The
+// Windows Recycle Bin is not supported yet.
+const TrashSupported = false
+
+// TrashFile is not supported on this platform
+func TrashFile(path string) (TrashedFile, error) {
+	return TrashedFile{}, fmt.Errorf("moving files to the trash is not supported on this platform; use move --target or delete instead")
+}
+
+// ListTrash is not supported on this platform
+func ListTrash() ([]TrashedFile, error) {
+	return nil, fmt.Errorf("listing the trash is not supported on this platform")
+}
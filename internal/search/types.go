@@ -14,6 +14,8 @@ type SearchResult struct {
 	Mode      os.FileMode
 	ModTime   time.Time
 	Hash      uint64    // Quick hash for duplicate detection
+	UID       int       // Owner user id, -1 if unavailable
+	GID       int       // Owner group id, -1 if unavailable
 	Error     error     // Error if occurred during processing
 }
 
@@ -41,7 +43,23 @@ type SearchOptions struct {
 	LowPriorityDirs  []string       // Directories for low priority search
 	StopChan         chan struct{}  // Channel for stopping the search
 	FileOp           FileOperationOptions
+	OnFileOpResult   func(OperationResult) // Called with the result of every file operation (optional)
 	ExcludeDirs      []string       // Directories to exclude from search
+	ReportErrors     bool           // Send unreadable paths as results with a *WalkError
+	Progress         *SearchProgress // Live counters, created by Search when nil
+	Summary          *SummaryCollector // Collects a SearchSummary of found files (optional)
+	EmptyFiles       bool           // Only match zero-byte files
+	EmptyDirs        bool           // Also send directories containing no files, only empty directories, as results
+	Owner            string         // Owner user name or uid
+	Group            string         // Owner group name or gid
+	PermMask         os.FileMode    // Mode bits to check, e.g. 0002 or os.ModeSetuid
+	Perm             os.FileMode    // Required value of the PermMask bits
+	TimeFilters      []TimeFilter   // Absolute mtime/ctime/atime ranges, all must match
+	SearchArchives   bool           // Match entries of zip, jar, tar, tar.gz and tar.zst files
+
+	ownerID int                                  // Owner resolved by Search
+	groupID int                                  // Group resolved by Search
+	onFile  func(path string, info os.FileInfo) // Takes matching files instead of results, without hashing them
 }
 
 // FileMetadata stores file metadata for quick comparison
@@ -92,6 +110,8 @@ const (
 	CopyFiles
 	MoveFiles
 	DeleteFiles
+	ArchiveFiles
+	TrashFiles
 )
 
 // FileOperationOptions contains settings for file operations
@@ -99,6 +119,29 @@ type FileOperationOptions struct {
 	Operation       FileOperation
 	TargetDir      string
 	ConflictPolicy ConflictResolutionPolicy
+
+	// Target layout for CopyFiles and MoveFiles; by default files are placed
+	// directly in TargetDir under their base name
+	Roots             []string // Directories target paths are relative to, usually the search roots or CommonDir
+	PreserveStructure bool     // Recreate each file's path relative to Roots under TargetDir
+	FlattenPaths      bool     // Encode the relative path into the name, e.g. docs__2024__report.pdf
+
+	PreserveAttributes AttributeFlags   // Source attributes to keep on copies, applied after the rename
+	Verify             bool             // Read copies back and compare their hash with the source before renaming
+	Limiter            *TransferLimiter // Bandwidth and concurrency limits shared by all copies, see NewTransferLimiter
+
+	DryRun bool           // Only work out what would happen, see PlanFileOperation
+	Plan   *OperationPlan // Collects dry run entries when set
+
+	Journal *Journal // Records every executed operation for auditing and UndoJournal
+
+	// ArchiveFiles settings
+	ArchiveFormat    ArchiveFormat
+	ArchiveName      string    // Archive file name without extension; defaults to koe-<timestamp>
+	CompressionLevel int       // 1 (fastest) to 9 (smallest); 0 uses the default level
+	Manifest         bool      // Add MANIFEST.txt with the hash and size of every entry
+	SplitSize        int64     // Start a new part once an archive reaches this size; 0 disables
+	Archiver         *Archiver // Shared archive written by HandleFileOperation, see NewArchiver
 }
 
 // ConflictResolutionPolicy defines how to handle file name conflicts
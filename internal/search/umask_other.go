@@ -0,0 +1,8 @@
+//go:build !unix
+
+package search
+
+import "os"
+
+// umask is zero on platforms without a file mode creation mask
+var umask os.FileMode
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"runtime"
 )
 
@@ -50,10 +51,13 @@ func shouldSkipDirectory(dir string, opts SearchOptions) bool {
 	return skip
 }
 
-// walkDirectoryOptimized processes a directory and its subdirectories with optimizations
-func walkDirectoryOptimized(dir string, paths chan<- string, opts SearchOptions) {
+// walkDirectoryOptimized processes a directory and its subdirectories with optimizations.
+// It reports whether the directory tree contains no files; skipped, unreadable
+// and partially walked directories never count as empty.
+func walkDirectoryOptimized(dir string, paths chan<- string, opts SearchOptions, processor *resultProcessor) bool {
+	opts.Progress.visitDir(dir)
 	if shouldSkipDirectory(dir, opts) {
-		return
+		return false
 	}
 
 	const batchSize = 1000
@@ -62,21 +66,31 @@ func walkDirectoryOptimized(dir string, paths chan<- string, opts SearchOptions)
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		logError("Failed to walk directory %s: %v", dir, err)
-		return
+		if opts.ReportErrors {
+			processor.addError("readdir", dir, err, opts.StopChan)
+		} else {
+			opts.Progress.failed()
+		}
+		return false
 	}
 	
 	dirs := make([]string, 0, len(entries))
+	files := 0
 	
 	for _, entry := range entries {
 		select {
 		case <-opts.StopChan:
-			return
+			return false
 		default:
 			path := filepath.Join(dir, entry.Name())
 			
 			if entry.IsDir() {
 				dirs = append(dirs, path)
-			} else if shouldProcessFile(path, opts) {
+				continue
+			}
+			
+			files++
+			if shouldProcessFile(path, opts) {
 				batch = append(batch, path)
 				if len(batch) >= batchSize {
 					sendBatch(batch, paths, opts.StopChan)
@@ -90,6 +104,15 @@ func walkDirectoryOptimized(dir string, paths chan<- string, opts SearchOptions)
 		sendBatch(batch, paths, opts.StopChan)
 	}
 	
+	opts.Progress.foundDirs(len(dirs))
+	opts.Progress.examinedFiles(files)
+
+	// Cleared by any subdirectory that is not an empty tree
+	empty := int32(0)
+	if files == 0 {
+		empty = 1
+	}
+
 	if len(dirs) > 0 {
 		var wg sync.WaitGroup
 		semaphore := make(chan struct{}, runtime.NumCPU())
@@ -97,7 +120,7 @@ func walkDirectoryOptimized(dir string, paths chan<- string, opts SearchOptions)
 		for _, subdir := range dirs {
 			select {
 			case <-opts.StopChan:
-				return
+				return false
 			case semaphore <- struct{}{}:
 				wg.Add(1)
 				go func(d string) {
@@ -105,12 +128,38 @@ func walkDirectoryOptimized(dir string, paths chan<- string, opts SearchOptions)
 						<-semaphore
 						wg.Done()
 					}()
-					walkDirectoryOptimized(d, paths, opts)
+					if !walkDirectoryOptimized(d, paths, opts, processor) {
+						atomic.StoreInt32(&empty, 0)
+					}
 				}(subdir)
 			}
 		}
 		wg.Wait()
 	}
+
+	select {
+	case <-opts.StopChan:
+		return false
+	default:
+	}
+
+	if atomic.LoadInt32(&empty) == 0 {
+		return false
+	}
+	if opts.EmptyDirs && !isRootDir(dir, opts.RootDirs) {
+		processor.addEmptyDir(dir, opts.StopChan)
+	}
+	return true
+}
+
+// isRootDir reports whether dir is one of the search roots
+func isRootDir(dir string, roots []string) bool {
+	for _, root := range roots {
+		if filepath.Clean(root) == dir {
+			return true
+		}
+	}
+	return false
 }
 
 // sendBatch sends a batch of files to the channel
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchEvent describes a change to a file matching the search options
+type WatchEvent struct {
+	Path  string
+	Op    string // "create", "write", "remove", "rename" or "chmod"
+	Time  time.Time
+	Error error // Watcher error, Path and Op are empty
+}
+
+// Watch monitors opts.RootDirs recursively and reports changes to files
+// matching the patterns and extensions. Directories created while watching
+// are added automatically. The channel is closed when opts.StopChan is closed.
+func Watch(opts SearchOptions) (<-chan WatchEvent, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %v", err)
+	}
+
+	for _, root := range opts.RootDirs {
+		if err := addWatchTree(watcher, root, opts); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	patterns := preparePatterns(opts)
+	events := make(chan WatchEvent, opts.BufferSize)
+
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-opts.StopChan:
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logError("Watcher error: %v", err)
+				select {
+				case events <- WatchEvent{Time: time.Now(), Error: err}:
+				case <-opts.StopChan:
+					return
+				}
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+						if err := addWatchTree(watcher, event.Name, opts); err != nil {
+							logError("Failed to watch new directory %s: %v", event.Name, err)
+						}
+						continue
+					}
+				}
+
+				if !matchesPatterns(event.Name, patterns, opts.IgnoreCase) {
+					continue
+				}
+
+				select {
+				case events <- WatchEvent{Path: event.Name, Op: watchOpName(event.Op), Time: time.Now()}:
+				case <-opts.StopChan:
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// addWatchTree adds dir and all its non-skipped subdirectories to the watcher
+func addWatchTree(watcher *fsnotify.Watcher, dir string, opts SearchOptions) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logError("Failed to walk directory %s: %v", path, err)
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && shouldSkipDirectory(path, opts) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			logError("Failed to watch %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// watchOpName converts an fsnotify operation to its event name
+func watchOpName(op fsnotify.Op) string {
+	switch {
+	case op.Has(fsnotify.Create):
+		return "create"
+	case op.Has(fsnotify.Write):
+		return "write"
+	case op.Has(fsnotify.Remove):
+		return "remove"
+	case op.Has(fsnotify.Rename):
+		return "rename"
+	default:
+		return "chmod"
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package search
+
+// readXattrs reports no extended attributes on platforms without support
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// writeXattrs is a no-op on platforms without extended attribute support
+func writeXattrs(path string, xattrs map[string][]byte) error {
+	return nil
+}
@@ -0,0 +1,68 @@
+//go:build linux || darwin
+
+package search
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the extended attributes of path. File systems without
+// xattr support yield no attributes.
+func readXattrs(path string) (map[string][]byte, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	list := make([]byte, size)
+	size, err = unix.Listxattr(path, list)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string][]byte)
+	for _, name := range bytes.Split(list[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		n, err := unix.Getxattr(path, attr, nil)
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, n)
+		if n > 0 {
+			if n, err = unix.Getxattr(path, attr, value); err != nil {
+				return nil, err
+			}
+		}
+		xattrs[attr] = value[:n]
+	}
+	return xattrs, nil
+}
+
+// writeXattrs sets extended attributes on path. Like cp -a, attributes the
+// target file system does not support (FAT, exFAT, NFS, tmpfs) or that need
+// privileges we lack (trusted.*, security.*) are logged and skipped.
+func writeXattrs(path string, xattrs map[string][]byte) error {
+	for name, value := range xattrs {
+		err := unix.Setxattr(path, name, value, 0)
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EPERM) {
+			logDebug("Cannot preserve extended attribute %s of %s: %v", name, path, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}